@@ -0,0 +1,117 @@
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ChangelogConfig は、GenerateChangelogの設定
+type ChangelogConfig struct {
+	// 見出し e.g. "v1.2.0". 指定がない場合は"Changelog"
+	Title string
+	// 出力する.endpoints.jsonのkey e.g. "guest-v1". 指定がない場合はすべて
+	Keys []string
+}
+
+// changelogSections are the sections of each .endpoints.json key, in order.
+func changelogSections() []string {
+	return []string{"Added", "Deprecated", "Removed", "Modified"}
+}
+
+// changelogSection returns the section a change of kind is listed in.
+func changelogSection(kind ChangeKind) string {
+	switch kind {
+	case ChangeVersionAdded, ChangeAPIAdded, ChangeParamAdded, ChangeFieldAdded:
+		return "Added"
+	case ChangeAPIDeprecated, ChangeFieldDeprecated:
+		return "Deprecated"
+	case ChangeVersionRemoved, ChangeAPIRemoved, ChangeParamRemoved, ChangeDefRemoved, ChangeFieldRemoved:
+		return "Removed"
+	case ChangeAPIRenamed, ChangePathChanged, ChangeMethodChanged, ChangeAuthChanged, ChangeStatusChanged,
+		ChangeRequestChanged, ChangeResponseChanged, ChangeFieldRequired, ChangeFieldOptional,
+		ChangeFieldTypeChanged, ChangeEnumChanged, ChangeParamRequired, ChangeParamOptional:
+		return "Modified"
+	default:
+		return "Modified"
+	}
+}
+
+// Changelog は、fromからtoへの変更を、.endpoints.jsonのkey (バージョン・フロントエンド) ごとにMarkdownで返す
+// from・toには、Load・ParseやLoadOpenApi・ParseOpenApiで読み込んだもの、EchoWrapper.Documentを指定できる
+func Changelog(from, to *Document, config ChangelogConfig) []byte {
+	var keys []string
+	changes := map[string][]Change{}
+	breaking := 0
+	for _, c := range Diff(from, to) {
+		if len(config.Keys) > 0 && !slices.Contains(config.Keys, c.Key) {
+			continue
+		}
+		if _, ok := changes[c.Key]; !ok {
+			keys = append(keys, c.Key)
+		}
+		changes[c.Key] = append(changes[c.Key], c)
+		if c.Breaking {
+			breaking++
+		}
+	}
+
+	title := config.Title
+	if title == "" {
+		title = "Changelog"
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n", title)
+	if len(keys) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.Bytes()
+	}
+	switch {
+	case breaking == 1:
+		b.WriteString("\n1 breaking change.\n")
+	case breaking > 1:
+		fmt.Fprintf(&b, "\n%d breaking changes.\n", breaking)
+	}
+
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n## %s\n", key)
+		for _, section := range changelogSections() {
+			var lines []string
+			for _, c := range changes[key] {
+				if changelogSection(c.Kind) == section {
+					lines = append(lines, changelogLine(c))
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", section)
+			for _, line := range lines {
+				b.WriteString(line + "\n")
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// GenerateChangelog は、Changelogをfilenameに出力する
+func GenerateChangelog(filename string, from, to *Document, config ChangelogConfig) error {
+	return os.WriteFile(filename, Changelog(from, to, config), 0o644)
+}
+
+func changelogLine(c Change) string {
+	line := "- "
+	if c.Breaking {
+		line += "**Breaking** "
+	}
+	switch {
+	case c.API != "":
+		line += fmt.Sprintf("`%s`: ", c.API)
+	case c.Field != "":
+		line += fmt.Sprintf("`%s.%s`: ", c.Def, c.Field)
+	case c.Def != "":
+		line += fmt.Sprintf("`%s`: ", c.Def)
+	}
+	return line + c.Message
+}
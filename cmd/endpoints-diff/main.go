@@ -0,0 +1,100 @@
+// endpoints-diff は、2つの.endpoints.json (またはOpenAPI) を比較し、変更を出力する
+// 破壊的な変更がある場合は終了コード1で終了するため、CIで利用できる
+// -changelogを指定した場合、変更をMarkdownの変更履歴としても出力する
+//
+//	endpoints-diff [-keys guest-v1,guest-v2] [-breaking] [-changelog CHANGELOG.md] old/.endpoints.json new/.endpoints.json
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/matsuri-tech/endpoints-go"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	keys := flag.String("keys", "", "比較する.endpoints.jsonのkey (カンマ区切り). 指定がない場合はすべて")
+	breakingOnly := flag.Bool("breaking", false, "破壊的な変更のみを出力する")
+	changelog := flag.String("changelog", "", "変更履歴をMarkdownで出力するファイル")
+	title := flag.String("title", "", "変更履歴の見出し e.g. v1.2.0")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: endpoints-diff [flags] <old> <new>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		return 2
+	}
+
+	from, err := load(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	to, err := load(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var targets []string
+	if *keys != "" {
+		targets = strings.Split(*keys, ",")
+	}
+	if *changelog != "" {
+		config := endpoints.ChangelogConfig{Title: *title, Keys: targets}
+		if err := endpoints.GenerateChangelog(*changelog, from, to, config); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	changes := endpoints.Diff(from, to)
+	if len(targets) > 0 {
+		changes = slices.DeleteFunc(changes, func(c endpoints.Change) bool {
+			return !slices.Contains(targets, c.Key)
+		})
+	}
+	breaking := endpoints.BreakingChanges(changes)
+	if *breakingOnly {
+		changes = breaking
+	}
+	for _, c := range changes {
+		fmt.Println(c)
+	}
+
+	if len(breaking) > 0 {
+		fmt.Fprintf(os.Stderr, "breaking changes: %d\n", len(breaking))
+		return 1
+	}
+	return 0
+}
+
+// load は、filenameを.endpoints.jsonか、OpenAPI (YAMLまたは"openapi"をもつJSON) として読み込む
+func load(filename string) (*endpoints.Document, error) {
+	bs, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if ext := filepath.Ext(filename); ext == ".yaml" || ext == ".yml" {
+		return endpoints.ParseOpenApi(bs)
+	}
+	var probe struct {
+		OpenApi string `json:"openapi"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(bs)).Decode(&probe); err == nil && probe.OpenApi != "" {
+		return endpoints.ParseOpenApi(bs)
+	}
+	return endpoints.Parse(bs)
+}
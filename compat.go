@@ -0,0 +1,42 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// このファイルには、Echoのバージョンによって実装が異なる処理をまとめている
+
+// swapResponseWriter は、cのレスポンスの書き込み先をwに差し替え、元の書き込み先と、元に戻す関数を返す
+func swapResponseWriter(c echo.Context, w http.ResponseWriter) (original http.ResponseWriter, restore func()) {
+	res := c.Response()
+	original = res.Writer
+	res.Writer = w
+	return original, func() {
+		res.Writer = original
+	}
+}
+
+// resetResponse は、書き込み済みとして扱われているcのレスポンスを未送信の状態に戻す
+func resetResponse(c echo.Context) {
+	res := c.Response()
+	res.Committed = false
+	res.Status = 0
+	res.Size = 0
+}
+
+// logResponseMismatch は、レスポンスが公開しているスキーマと一致しないことをログに出力する
+func logResponseMismatch(c echo.Context, api API, err error) {
+	c.Logger().Errorf("endpoints: response of %s does not match the published schema: %v", api.Name, err)
+}
+
+// bindQueryParams は、cのクエリパラメータをqueryタグのフィールドにBindする
+func bindQueryParams(c echo.Context, r any) error {
+	return (&echo.DefaultBinder{}).BindQueryParams(c, r)
+}
+
+// bindHeaders は、cのヘッダをheaderタグのフィールドにBindする
+func bindHeaders(c echo.Context, r any) error {
+	return (&echo.DefaultBinder{}).BindHeaders(c, r)
+}
@@ -0,0 +1,528 @@
+package endpoints
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// ChangeKind は、Diffが検出する変更の種類
+type ChangeKind string
+
+const (
+	ChangeVersionAdded     ChangeKind = "version_added"
+	ChangeVersionRemoved   ChangeKind = "version_removed"
+	ChangeAPIAdded         ChangeKind = "api_added"
+	ChangeAPIRemoved       ChangeKind = "api_removed"
+	ChangeAPIRenamed       ChangeKind = "api_renamed"
+	ChangeAPIDeprecated    ChangeKind = "api_deprecated"
+	ChangePathChanged      ChangeKind = "path_changed"
+	ChangeMethodChanged    ChangeKind = "method_changed"
+	ChangeAuthChanged      ChangeKind = "auth_changed"
+	ChangeStatusChanged    ChangeKind = "status_changed"
+	ChangeRequestChanged   ChangeKind = "request_changed"
+	ChangeResponseChanged  ChangeKind = "response_changed"
+	ChangeParamAdded       ChangeKind = "param_added"
+	ChangeParamRemoved     ChangeKind = "param_removed"
+	ChangeParamRequired    ChangeKind = "param_required"
+	ChangeParamOptional    ChangeKind = "param_optional"
+	ChangeDefRemoved       ChangeKind = "def_removed"
+	ChangeFieldAdded       ChangeKind = "field_added"
+	ChangeFieldRemoved     ChangeKind = "field_removed"
+	ChangeFieldRequired    ChangeKind = "field_required"
+	ChangeFieldOptional    ChangeKind = "field_optional"
+	ChangeFieldTypeChanged ChangeKind = "field_type_changed"
+	ChangeFieldDeprecated  ChangeKind = "field_deprecated"
+	ChangeEnumChanged      ChangeKind = "enum_changed"
+)
+
+// Change は、2つのDocumentの間の1つの変更
+type Change struct {
+	// .endpoints.jsonのkey e.g. "v1", "guest-v1"
+	Key  string
+	Kind ChangeKind
+	// 既存のクライアントが動かなくなりうる変更かどうか
+	Breaking bool
+	// 変更されたエンドポイントのDesc.Name. $defsの変更の場合は空文字列
+	API string
+	// 変更された$defsの型とフィールド e.g. "SampleModel", "owner.name"
+	// 型自体の変更の場合、Fieldは空文字列
+	Def   string
+	Field string
+	// 変更の説明 e.g. "path changed from samples/:id to samples/:sampleId"
+	Message string
+}
+
+func (c Change) String() string {
+	var b strings.Builder
+	if c.Breaking {
+		b.WriteString("BREAKING ")
+	}
+	fmt.Fprintf(&b, "[%s] ", c.Key)
+	switch {
+	case c.API != "":
+		b.WriteString(c.API + ": ")
+	case c.Field != "":
+		b.WriteString(c.Def + "." + c.Field + ": ")
+	case c.Def != "":
+		b.WriteString(c.Def + ": ")
+	}
+	b.WriteString(c.Message)
+	return b.String()
+}
+
+// BreakingChanges は、changesのうち破壊的な変更のみを返す
+func BreakingChanges(changes []Change) []Change {
+	var breaking []Change
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// Diff は、fromからtoへの変更を.endpoints.jsonのkeyごとに返す
+// エンドポイントの削除・名前の変更・pathやmethodの変更、$defsのフィールドの削除や必須化・型の変更、認証の変更などは
+// 破壊的な変更 (Change.Breaking) として返される
+//
+// $defsの変更は、そのkeyのエンドポイントから参照されている型についてのみ返される
+func Diff(from, to *Document) []Change {
+	var changes []Change
+	for _, ov := range from.Versions {
+		nv, ok := findDocumentVersion(to, ov.Key)
+		if !ok {
+			changes = append(changes, Change{Key: ov.Key, Kind: ChangeVersionRemoved, Breaking: true, Message: "version removed"})
+			continue
+		}
+		d := &differ{key: ov.Key, oldDefs: from.Defs, newDefs: to.Defs}
+		d.diffVersion(ov, nv)
+		changes = append(changes, d.changes...)
+	}
+	for _, nv := range to.Versions {
+		if _, ok := findDocumentVersion(from, nv.Key); !ok {
+			changes = append(changes, Change{Key: nv.Key, Kind: ChangeVersionAdded, Message: "version added"})
+		}
+	}
+	return changes
+}
+
+func findDocumentVersion(doc *Document, key string) (DocumentVersion, bool) {
+	for _, v := range doc.Versions {
+		if v.Key == key {
+			return v, true
+		}
+	}
+	return DocumentVersion{}, false
+}
+
+// differ collects the changes of a single .endpoints.json key.
+type differ struct {
+	key     string
+	oldDefs jsonschema.Definitions
+	newDefs jsonschema.Definitions
+	changes []Change
+}
+
+func (d *differ) add(c Change) {
+	c.Key = d.key
+	d.changes = append(d.changes, c)
+}
+
+// defUsage tells whether a $defs type is sent by clients, received by them, or both.
+type defUsage struct {
+	request  bool
+	response bool
+}
+
+// documentAPIPath returns the path of api without the query string of Desc.Query,
+// whose values are only examples.
+func documentAPIPath(api DocumentAPI) string {
+	path, _, _ := strings.Cut(api.Path, "?")
+	return path
+}
+
+func (d *differ) diffVersion(ov, nv DocumentVersion) {
+	// A removed endpoint whose method and path are taken by an added one is considered renamed
+	renamed := map[string]DocumentAPI{}
+	for _, o := range ov.APIs {
+		if _, ok := nv.API(o.Name); ok {
+			continue
+		}
+		for _, n := range nv.APIs {
+			if _, ok := ov.API(n.Name); ok {
+				continue
+			}
+			if n.Method == o.Method && documentAPIPath(n) == documentAPIPath(o) {
+				renamed[o.Name] = n
+				break
+			}
+		}
+	}
+
+	added := map[string]bool{}
+	for _, n := range nv.APIs {
+		added[n.Name] = true
+	}
+	for _, o := range ov.APIs {
+		n, ok := nv.API(o.Name)
+		if !ok {
+			n, ok = renamed[o.Name]
+			if !ok {
+				d.add(Change{Kind: ChangeAPIRemoved, Breaking: true, API: o.Name, Message: fmt.Sprintf("%s %s removed", o.Method, o.Path)})
+				continue
+			}
+			d.add(Change{Kind: ChangeAPIRenamed, Breaking: true, API: o.Name, Message: fmt.Sprintf("renamed to %s", n.Name)})
+		}
+		delete(added, n.Name)
+		d.diffAPI(o, n)
+	}
+	for _, n := range nv.APIs {
+		if added[n.Name] {
+			d.add(Change{Kind: ChangeAPIAdded, API: n.Name, Message: fmt.Sprintf("%s %s added", n.Method, n.Path)})
+		}
+	}
+
+	oldUsage := d.usage(ov, d.oldDefs)
+	names := make([]string, 0, len(oldUsage))
+	for name := range oldUsage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		n, ok := d.newDefs[name]
+		if !ok {
+			d.add(Change{Kind: ChangeDefRemoved, Breaking: true, Def: name, Message: "type removed"})
+			continue
+		}
+		d.diffSchema(name, "", d.oldDefs[name], n, oldUsage[name])
+	}
+}
+
+func (d *differ) diffAPI(o, n DocumentAPI) {
+	change := func(kind ChangeKind, breaking bool, format string, args ...any) {
+		d.add(Change{Kind: kind, Breaking: breaking, API: o.Name, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if documentAPIPath(o) != documentAPIPath(n) {
+		change(ChangePathChanged, true, "path changed from %s to %s", documentAPIPath(o), documentAPIPath(n))
+	}
+	if o.Method != n.Method {
+		change(ChangeMethodChanged, true, "method changed from %s to %s", o.Method, n.Method)
+	}
+	if !o.Deprecated && n.Deprecated {
+		change(ChangeAPIDeprecated, false, "deprecated")
+	}
+	if o.AuthSchema != n.AuthSchema {
+		// Dropping authentication does not break clients sending credentials
+		change(ChangeAuthChanged, n.AuthSchema.Type != "", "auth changed from %s to %s", authLabel(o.AuthSchema), authLabel(n.AuthSchema))
+	}
+	if documentStatus(o) != documentStatus(n) {
+		change(ChangeStatusChanged, true, "status changed from %d to %d", documentStatus(o), documentStatus(n))
+	}
+
+	switch ot, nt := schemaLabel(o.Request), schemaLabel(n.Request); {
+	case o.Request == nil && n.Request != nil:
+		change(ChangeRequestChanged, true, "request body %s added", nt)
+	case o.Request != nil && n.Request == nil:
+		change(ChangeRequestChanged, false, "request body %s removed", ot)
+	case ot != nt:
+		change(ChangeRequestChanged, true, "request changed from %s to %s", ot, nt)
+	}
+	switch ot, nt := schemaLabel(o.Response), schemaLabel(n.Response); {
+	case o.Response == nil && n.Response != nil:
+		change(ChangeResponseChanged, false, "response body %s added", nt)
+	case o.Response != nil && n.Response == nil:
+		change(ChangeResponseChanged, true, "response body %s removed", ot)
+	case ot != nt:
+		change(ChangeResponseChanged, true, "response changed from %s to %s", ot, nt)
+	}
+
+	d.diffParams(o, n)
+}
+
+func (d *differ) diffParams(o, n DocumentAPI) {
+	for _, in := range []string{"path", "query", "header"} {
+		oldParams, newParams := o.Params.schema(in), n.Params.schema(in)
+		for _, name := range propertyNames(newParams) {
+			if !hasProperty(oldParams, name) {
+				required := slices.Contains(newParams.Required, name)
+				label := "optional"
+				if required {
+					label = "required"
+				}
+				d.add(Change{
+					Kind: ChangeParamAdded, Breaking: required && in != "path", API: o.Name,
+					Message: fmt.Sprintf("%s %s parameter %s added", label, in, name),
+				})
+			}
+		}
+		for _, name := range propertyNames(oldParams) {
+			np, ok := propertyOf(newParams, name)
+			if !ok {
+				d.add(Change{Kind: ChangeParamRemoved, API: o.Name, Message: fmt.Sprintf("%s parameter %s removed", in, name)})
+				continue
+			}
+			wasRequired, isRequired := slices.Contains(oldParams.Required, name), slices.Contains(newParams.Required, name)
+			switch {
+			case !wasRequired && isRequired:
+				d.add(Change{
+					Kind: ChangeParamRequired, Breaking: in != "path", API: o.Name,
+					Message: fmt.Sprintf("%s parameter %s became required", in, name),
+				})
+			case wasRequired && !isRequired:
+				d.add(Change{Kind: ChangeParamOptional, API: o.Name, Message: fmt.Sprintf("%s parameter %s became optional", in, name)})
+			}
+			op, _ := oldParams.Properties.Get(name)
+			d.diffParamSchema(o.Name, in, name, op, np)
+		}
+	}
+}
+
+// diffParamSchema compares the schema of a parameter present on both sides like a field of a $defs type
+// sent by clients, reporting the changes on the endpoint.
+// Referenced types are compared as $defs of their own.
+func (d *differ) diffParamSchema(api, in, name string, o, n *jsonschema.Schema) {
+	sub := &differ{key: d.key, oldDefs: d.oldDefs, newDefs: d.newDefs}
+	sub.diffSchema("", "", o, n, defUsage{request: true})
+	for _, c := range sub.changes {
+		target := name
+		switch {
+		case strings.HasPrefix(c.Field, "[]"):
+			target += c.Field
+		case c.Field != "":
+			target += "." + c.Field
+		}
+		c.API, c.Def, c.Field = api, "", ""
+		c.Message = fmt.Sprintf("%s parameter %s: %s", in, target, c.Message)
+		d.add(c)
+	}
+}
+
+// usage returns the $defs reachable from the endpoints of v, and how they are used.
+func (d *differ) usage(v DocumentVersion, defs jsonschema.Definitions) map[string]defUsage {
+	usage := map[string]defUsage{}
+	var walk func(s *jsonschema.Schema, request bool)
+	walk = func(s *jsonschema.Schema, request bool) {
+		for _, name := range schemaRefs(s) {
+			u := usage[name]
+			if (request && u.request) || (!request && u.response) {
+				continue
+			}
+			if request {
+				u.request = true
+			} else {
+				u.response = true
+			}
+			usage[name] = u
+			if def, ok := defs[name]; ok {
+				walk(def, request)
+			}
+		}
+	}
+	for _, api := range v.APIs {
+		walk(api.Request, true)
+		walk(api.Response, false)
+		for _, er := range api.Errors {
+			walk(er.Body, false)
+		}
+		// Parameters are sent by clients like the request body
+		for _, in := range []string{"path", "query", "header"} {
+			walk(api.Params.schema(in), true)
+		}
+	}
+	return usage
+}
+
+// diffSchema compares the schema of the $defs type def at field, reporting field level changes.
+// Whether a change breaks clients depends on whether they send or receive the type.
+func (d *differ) diffSchema(def, field string, o, n *jsonschema.Schema, usage defUsage) {
+	change := func(kind ChangeKind, breaking bool, format string, args ...any) {
+		d.add(Change{Kind: kind, Breaking: breaking, Def: def, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ot, nt := schemaLabel(o), schemaLabel(n); ot != nt {
+		change(ChangeFieldTypeChanged, true, "type changed from %s to %s", ot, nt)
+		return
+	}
+	if !o.Deprecated && n.Deprecated && field != "" {
+		change(ChangeFieldDeprecated, false, "deprecated")
+	}
+	if removed, added := enumDiff(o.Enum, n.Enum); len(removed) > 0 || len(added) > 0 {
+		// Clients may send removed values, and may not handle added ones
+		breaking := (len(removed) > 0 && usage.request) || (len(added) > 0 && usage.response)
+		var parts []string
+		if len(added) > 0 {
+			parts = append(parts, "added "+strings.Join(added, ", "))
+		}
+		if len(removed) > 0 {
+			parts = append(parts, "removed "+strings.Join(removed, ", "))
+		}
+		change(ChangeEnumChanged, breaking, "enum values %s", strings.Join(parts, ", "))
+	}
+
+	child := func(name string) string {
+		if field == "" {
+			return name
+		}
+		return field + "." + name
+	}
+	for _, name := range propertyNames(o) {
+		op, _ := o.Properties.Get(name)
+		np, ok := propertyOf(n, name)
+		if !ok {
+			d.add(Change{Kind: ChangeFieldRemoved, Breaking: true, Def: def, Field: child(name), Message: "field removed"})
+			continue
+		}
+		wasRequired, isRequired := slices.Contains(o.Required, name), slices.Contains(n.Required, name)
+		switch {
+		case !wasRequired && isRequired:
+			d.add(Change{Kind: ChangeFieldRequired, Breaking: usage.request, Def: def, Field: child(name), Message: "field became required"})
+		case wasRequired && !isRequired:
+			d.add(Change{Kind: ChangeFieldOptional, Breaking: usage.response, Def: def, Field: child(name), Message: "field became optional"})
+		}
+		// Referenced types are compared as $defs of their own
+		if op.Ref == "" {
+			d.diffSchema(def, child(name), op, np, usage)
+		}
+	}
+	for _, name := range propertyNames(n) {
+		if !hasProperty(o, name) {
+			required := slices.Contains(n.Required, name)
+			message := "optional field added"
+			if required {
+				message = "required field added"
+			}
+			d.add(Change{Kind: ChangeFieldAdded, Breaking: required && usage.request, Def: def, Field: child(name), Message: message})
+		}
+	}
+	if o.Items != nil && n.Items != nil && o.Items.Ref == "" {
+		d.diffSchema(def, field+"[]", o.Items, n.Items, usage)
+	}
+}
+
+func propertyNames(s *jsonschema.Schema) []string {
+	if s == nil || s.Properties == nil {
+		return nil
+	}
+	var names []string
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		names = append(names, pair.Key)
+	}
+	return names
+}
+
+func propertyOf(s *jsonschema.Schema, name string) (*jsonschema.Schema, bool) {
+	if s == nil || s.Properties == nil {
+		return nil, false
+	}
+	return s.Properties.Get(name)
+}
+
+func hasProperty(s *jsonschema.Schema, name string) bool {
+	_, ok := propertyOf(s, name)
+	return ok
+}
+
+// enumDiff returns the enum values removed from and added to o, formatted as JSON.
+func enumDiff(o, n []any) (removed, added []string) {
+	format := func(values []any) []string {
+		var strs []string
+		for _, v := range values {
+			strs = append(strs, markdownLiteral(v))
+		}
+		return strs
+	}
+	oldValues, newValues := format(o), format(n)
+	if len(oldValues) == 0 || len(newValues) == 0 {
+		// An enum added or removed as a whole restricts or widens the type instead
+		return nil, nil
+	}
+	for _, v := range oldValues {
+		if !slices.Contains(newValues, v) {
+			removed = append(removed, v)
+		}
+	}
+	for _, v := range newValues {
+		if !slices.Contains(oldValues, v) {
+			added = append(added, v)
+		}
+	}
+	return removed, added
+}
+
+// schemaLabel returns a short description of the type of s, used to detect type changes.
+// Validation keywords are not part of it.
+func schemaLabel(s *jsonschema.Schema) string {
+	if s == nil {
+		return "none"
+	}
+	if s.Ref != "" {
+		return strings.TrimPrefix(s.Ref, "#/$defs/")
+	}
+	if subs := append(slices.Clone(s.OneOf), s.AnyOf...); len(subs) > 0 {
+		labels := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			labels = append(labels, schemaLabel(sub))
+		}
+		return strings.Join(labels, " | ")
+	}
+	if len(s.AllOf) > 0 {
+		labels := make([]string, 0, len(s.AllOf))
+		for _, sub := range s.AllOf {
+			labels = append(labels, schemaLabel(sub))
+		}
+		return strings.Join(labels, " & ")
+	}
+
+	label := s.Type
+	switch s.Type {
+	case "":
+		label = "any"
+	case "array":
+		label = schemaLabel(s.Items) + "[]"
+	case "object":
+		if s.Properties == nil && s.AdditionalProperties != nil && s.AdditionalProperties.Not == nil {
+			label = "map[string]" + schemaLabel(s.AdditionalProperties)
+		}
+	}
+	if s.Format != "" {
+		label += "(" + s.Format + ")"
+	}
+	return label
+}
+
+func authLabel(a AuthSchema) string {
+	if a.Type == "" {
+		return "none"
+	}
+	return fmt.Sprintf("%s (%s)", a.Type, a.Header)
+}
+
+// schema returns the schema of the in ("path", "query" or "header") parameters.
+func (p *DocumentParams) schema(in string) *jsonschema.Schema {
+	if p == nil {
+		return nil
+	}
+	switch in {
+	case "path":
+		return p.Path
+	case "query":
+		return p.Query
+	case "header":
+		return p.Header
+	default:
+		return nil
+	}
+}
+
+func documentStatus(api DocumentAPI) int {
+	if api.Status == 0 {
+		return http.StatusOK
+	}
+	return api.Status
+}
@@ -0,0 +1,61 @@
+package endpoints
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+// DocsConfig は、EchoWrapper.ServeDocsの設定
+type DocsConfig struct {
+	// falseの場合、ハンドラを登録しない
+	// 本番環境で公開しないよう、e.g. Enabled: os.Getenv("ENV") != "prod" のように指定する
+	Enabled bool
+	// 表示するOpenAPIの設定
+	OpenApi OpenApiGeneratorConfig
+}
+
+// ServeDocs は、登録されたエンドポイントのOpenAPIを表示するドキュメントのページをpathに登録する
+// ページは外部のCDNなどに依存せず、表示するOpenAPIはpath + "/openapi.json"から取得される
+// e.g. ew.ServeDocs("/_docs", endpoints.DocsConfig{Enabled: isDev})
+func (w *EchoWrapper) ServeDocs(path string, config DocsConfig, m ...echo.MiddlewareFunc) {
+	if !config.Enabled {
+		return
+	}
+	prefix := strings.TrimSuffix(path, "/")
+	specPath := prefix + "/openapi.json"
+	page := template.Must(template.New("docs").Parse(docsHTML))
+
+	w.Echo.GET(prefix+"/", w.docsHandler(page, specPath), m...)
+	if prefix != "" {
+		w.Echo.GET(prefix, w.docsHandler(page, specPath), m...)
+	}
+	w.ServeOpenApiJson(specPath, config.OpenApi, m...)
+}
+
+func (w *EchoWrapper) docsHandler(page *template.Template, specPath string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		versions := []string{}
+		for _, env := range w.endpoints.env {
+			versions = append(versions, env.Version)
+		}
+		frontends := append([]string{}, w.endpoints.frontends...)
+
+		var b strings.Builder
+		err := page.Execute(&b, map[string]any{
+			"specURL":   specPath,
+			"versions":  versions,
+			"frontends": frontends,
+		})
+		if err != nil {
+			return err
+		}
+		return c.HTML(http.StatusOK, b.String())
+	}
+}
@@ -0,0 +1,208 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iancoleman/orderedmap"
+	"github.com/invopop/jsonschema"
+)
+
+// Document は、.endpoints.jsonを読み込んだもの
+// 他のサービスが生成した.endpoints.jsonをGoから利用するために使う
+type Document struct {
+	// .endpoints.jsonのkey ("v1", "guest-v1"など) ごとの定義. ファイルでの順序を保つ
+	Versions []DocumentVersion
+	// エラーカタログ ($errors)
+	Errors []ErrorCode
+	// リクエスト・レスポンスの型のスキーマ ($defs)
+	Defs jsonschema.Definitions
+}
+
+// DocumentVersion は、.endpoints.jsonのkeyごとの定義
+type DocumentVersion struct {
+	// e.g. "v1", "guest-v1"
+	Key string
+	Env Domain
+	// ファイルでの順序を保つ
+	APIs []DocumentAPI
+}
+
+// DocumentAPI は、.endpoints.jsonのエンドポイントの定義
+type DocumentAPI struct {
+	// Desc.Name
+	Name string `json:"-"`
+	// 先頭の"/"を含まないパス. Desc.Queryが指定されている場合はクエリ文字列を含む e.g. "samples/:id?yearMonth=2021-01"
+	Path       string             `json:"path"`
+	Desc       string             `json:"desc"`
+	Method     string             `json:"method"`
+	AuthSchema AuthSchema         `json:"authSchema"`
+	Request    *jsonschema.Schema `json:"request"`
+	Response   *jsonschema.Schema `json:"response"`
+	Params     *DocumentParams    `json:"params,omitempty"`
+	Tags       []string           `json:"tags,omitempty"`
+	Deprecated bool               `json:"deprecated,omitempty"`
+	Metadata   map[string]string  `json:"metadata,omitempty"`
+	// 0の場合は200
+	Status int                     `json:"status,omitempty"`
+	Errors []DocumentErrorResponse `json:"errors,omitempty"`
+	// レスポンスの例
+	Example any `json:"example,omitempty"`
+}
+
+// DocumentParams は、エンドポイントのパラメータのスキーマ
+type DocumentParams struct {
+	Path   *jsonschema.Schema `json:"path,omitempty"`
+	Query  *jsonschema.Schema `json:"query,omitempty"`
+	Header *jsonschema.Schema `json:"header,omitempty"`
+}
+
+// DocumentErrorResponse は、エンドポイントが返しうるエラーレスポンス
+type DocumentErrorResponse struct {
+	Status int                `json:"status"`
+	Code   string             `json:"code,omitempty"`
+	Desc   string             `json:"desc"`
+	Body   *jsonschema.Schema `json:"body"`
+}
+
+// Load は、filenameの.endpoints.jsonを読み込む
+func Load(filename string) (*Document, error) {
+	bs, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bs)
+}
+
+// Parse は、.endpoints.jsonの内容を読み込む
+func Parse(data []byte) (*Document, error) {
+	// keyの順序を保つため、orderedmapでkeyの一覧を得る
+	keys := orderedmap.New()
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	for _, key := range keys.Keys() {
+		switch key {
+		case "$errors":
+			if err := json.Unmarshal(raw[key], &doc.Errors); err != nil {
+				return nil, fmt.Errorf("$errors: %w", err)
+			}
+		case "$defs":
+			if err := json.Unmarshal(raw[key], &doc.Defs); err != nil {
+				return nil, fmt.Errorf("$defs: %w", err)
+			}
+		default:
+			v, err := parseDocumentVersion(key, raw[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			doc.Versions = append(doc.Versions, v)
+		}
+	}
+	return doc, nil
+}
+
+// Document は、登録されたエンドポイントの.endpoints.jsonをDocumentとして返す
+// Loadで読み込んだ他のサービスの.endpoints.jsonと同じように扱うために使う
+func (w *EchoWrapper) Document() (*Document, error) {
+	if err := w.endpoints.validate(); err != nil {
+		return nil, err
+	}
+	bs, err := w.endpoints.generateJson()
+	if err != nil {
+		return nil, err
+	}
+	return Parse(bs)
+}
+
+func parseDocumentVersion(key string, data json.RawMessage) (DocumentVersion, error) {
+	var v struct {
+		Env Domain          `json:"env"`
+		API json.RawMessage `json:"api"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return DocumentVersion{}, err
+	}
+
+	version := DocumentVersion{Key: key, Env: v.Env}
+	if len(v.API) == 0 {
+		return version, nil
+	}
+	names := orderedmap.New()
+	if err := json.Unmarshal(v.API, names); err != nil {
+		return DocumentVersion{}, err
+	}
+	var apis map[string]DocumentAPI
+	if err := json.Unmarshal(v.API, &apis); err != nil {
+		return DocumentVersion{}, err
+	}
+	for _, name := range names.Keys() {
+		api := apis[name]
+		api.Name = name
+		version.APIs = append(version.APIs, api)
+	}
+	return version, nil
+}
+
+// documentKeyName は、versionとfrontendに対応する.endpoints.jsonのkeyを返す e.g. "v1", "guest-v1"
+func documentKeyName(version, frontend string) string {
+	if frontend == "" {
+		return version
+	}
+	return fmt.Sprintf("%s-%s", frontend, version)
+}
+
+// Version は、versionとfrontendに対応する定義を返す
+// frontendが空文字列の場合、フロントエンドで絞り込まれていない定義を返す
+func (d *Document) Version(version, frontend string) (DocumentVersion, bool) {
+	key := documentKeyName(version, frontend)
+	for _, v := range d.Versions {
+		if v.Key == key {
+			return v, true
+		}
+	}
+	return DocumentVersion{}, false
+}
+
+// API は、nameのエンドポイントを返す
+func (v DocumentVersion) API(name string) (DocumentAPI, bool) {
+	for _, api := range v.APIs {
+		if api.Name == name {
+			return api, true
+		}
+	}
+	return DocumentAPI{}, false
+}
+
+// URL は、version・frontendのnameのエンドポイントの、stageでのURLを返す
+// パスパラメータ (":id"など) やDesc.Queryのクエリ文字列はそのまま含まれる
+func (d *Document) URL(name, version, frontend string, stage Stage) (string, error) {
+	v, ok := d.Version(version, frontend)
+	if !ok {
+		return "", fmt.Errorf("unknown version: %s", documentKeyName(version, frontend))
+	}
+	api, ok := v.API(name)
+	if !ok {
+		return "", fmt.Errorf("unknown api: %s", name)
+	}
+	base := v.Env.URL(stage)
+	if base == "" {
+		return "", fmt.Errorf("no url for stage %q in %s", stage, v.Key)
+	}
+	return strings.TrimSuffix(base, "/") + "/" + api.Path, nil
+}
+
+// Def は、$defsのスキーマを返す
+// refには"SampleModel"のような名前と、"#/$defs/SampleModel"のような$refのどちらも指定できる
+func (d *Document) Def(ref string) (*jsonschema.Schema, bool) {
+	s, ok := d.Defs[strings.TrimPrefix(ref, "#/$defs/")]
+	return s, ok
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/invopop/jsonschema"
@@ -19,9 +20,10 @@ import (
 )
 
 type endpoints struct {
-	env       []Env
-	frontends []string
-	api       []API
+	env        []Env
+	frontends  []string
+	api        []API
+	errorCodes []ErrorCode
 }
 
 func (e *endpoints) addEnv(env ...Env) {
@@ -36,6 +38,20 @@ func (e *endpoints) addFrontends(frontends ...string) {
 	e.frontends = append(e.frontends, frontends...)
 }
 
+func (e *endpoints) addErrorCodes(codes ...ErrorCode) {
+	e.errorCodes = append(e.errorCodes, codes...)
+}
+
+// errorCode は、エラーカタログに登録されたcodeのErrorCodeを返す
+func (e *endpoints) errorCode(code string) (ErrorCode, bool) {
+	for _, c := range e.errorCodes {
+		if c.Code == code {
+			return c, true
+		}
+	}
+	return ErrorCode{}, false
+}
+
 func (e *endpoints) validate() error {
 	// 重複したnameと、重複したpathとmethodの組み合わせがないかチェック
 	names := map[string]struct{}{}
@@ -51,28 +67,67 @@ func (e *endpoints) validate() error {
 		}
 		paths[v.Path+v.Method] = struct{}{}
 	}
+
+	// 重複したエラーコードがないかチェック
+	codes := map[string]struct{}{}
+	for _, c := range e.errorCodes {
+		if _, ok := codes[c.Code]; ok {
+			return fmt.Errorf("duplicate error code: %s", c.Code)
+		}
+		codes[c.Code] = struct{}{}
+	}
 	return nil
 }
 
+// documentKey は、.endpoints.jsonのトップレベルのkey ("v1", "manager-v1"など) と、
+// それに対応する環境とフロントエンドを表す
+type documentKey struct {
+	key      string
+	env      Env
+	frontend string
+}
+
+// documentKeys は、.endpoints.jsonに出力される順にdocumentKeyを返す
+func (e *endpoints) documentKeys() []documentKey {
+	var keys []documentKey
+	for _, v := range e.env {
+		keys = append(keys, documentKey{key: documentKeyName(v.Version, ""), env: v})
+		for _, f := range e.frontends {
+			// "manager-v1"のようなkeyを生成する
+			keys = append(keys, documentKey{key: documentKeyName(v.Version, f), env: v, frontend: f})
+		}
+	}
+	return keys
+}
+
 func (e *endpoints) generateJson() ([]byte, error) {
+	return e.generateJsonFiltered("", "")
+}
+
+// generateJsonFiltered は、versionとfrontendに該当するkeyのみの.endpoints.jsonを生成する
+// versionやfrontendが空文字列の場合、その条件では絞り込まない. $errorsと$defsは常にすべて出力する
+func (e *endpoints) generateJsonFiltered(version, frontend string) ([]byte, error) {
 	merged, renames := mergeDefs(e.collectAllDefs())
 
 	endpoints := orderedmap.New()
-	for _, v := range e.env {
+	for _, k := range e.documentKeys() {
+		if (version != "" && k.env.Version != version) || (frontend != "" && k.frontend != frontend) {
+			continue
+		}
 		version := orderedmap.New()
-		version.Set("env", v.Domain)
-		version.Set("api", e.generateAPIList(v.Version, renames))
-		endpoints.Set(v.Version, version)
-
-		for _, f := range e.frontends {
-			byFrontend := orderedmap.New()
-			byFrontend.Set("env", v.Domain)
-			byFrontend.Set("api", e.generateAPIListByFrontend(v.Version, f, renames))
-			// "manager-v1"のようなkeyを生成してそこに属するAPIの一覧をセットする
-			endpoints.Set(fmt.Sprintf("%s-%s", f, v.Version), byFrontend)
+		version.Set("env", k.env.Domain)
+		if k.frontend == "" {
+			version.Set("api", e.generateAPIList(k.env.Version, renames))
+		} else {
+			// keyに属するAPIの一覧をセットする
+			version.Set("api", e.generateAPIListByFrontend(k.env.Version, k.frontend, renames))
 		}
+		endpoints.Set(k.key, version)
 	}
 
+	if len(e.errorCodes) > 0 {
+		endpoints.Set("$errors", e.generateErrorCodeList())
+	}
 	endpoints.Set("$defs", merged)
 
 	var b bytes.Buffer
@@ -114,6 +169,11 @@ func (e *endpoints) generate(filename string) error {
 	return nil
 }
 
+const (
+	OpenApiVersion30 = "3.0.0"
+	OpenApiVersion31 = "3.1.0"
+)
+
 type OpenApiGeneratorConfig struct {
 	Title        string
 	Desc         string
@@ -121,7 +181,28 @@ type OpenApiGeneratorConfig struct {
 		Prefix string
 		Tag    string
 	}
+	// AuthSchemaが指定されていないエンドポイントに適用する認証ヘッダ (apiKey)
+	// 指定がない場合、AuthSchemaが指定されていないエンドポイントは認証不要として出力される
+	// NewNoneAuthSchemaが指定されたエンドポイントには適用されない
 	AuthHeader string
+
+	// 出力するOpenAPIのバージョン。OpenApiVersion30 または OpenApiVersion31
+	// 指定がない場合、OpenApiVersion30 とみなす
+	// OpenApiVersion31 の場合、JSON Schema 2020-12 のスキーマが変換されずにそのまま出力される
+	OpenApiVersion string
+
+	// 指定した場合、そのバージョンに含まれるエンドポイントと、それらが参照するスキーマのみを出力する
+	// serversもそのバージョンのEnv.Domainに限られる
+	Version string
+	// 指定した場合、そのフロントエンド向けのエンドポイントと、それらが参照するスキーマのみを出力する
+	Frontend string
+}
+
+func (c OpenApiGeneratorConfig) openApiVersion() string {
+	if c.OpenApiVersion == "" {
+		return OpenApiVersion30
+	}
+	return c.OpenApiVersion
 }
 
 // isEmptySchema returns true if the schema is "empty" (i.e., has no type, properties, or constraints).
@@ -160,7 +241,31 @@ func convertJSONSchemaToSchemaRef(js *jsonschema.Schema, defs jsonschema.Definit
 	}
 }
 
-// convertJSONSchemaDefToOpenAPI converts a single JSON Schema definition to OpenAPI Schema
+// schemaConverter converts reflected JSON Schemas into OpenAPI schema references.
+// For OpenAPI 3.0 the schemas are converted with convertJSONSchemaToSchemaRef.
+// For OpenAPI 3.1 the schemas are kept as is in raw, and a placeholder $ref pointing at
+// the raw schema is returned instead, to be substituted by openAPI31Document.
+type schemaConverter struct {
+	defs      jsonschema.Definitions
+	openAPI31 bool
+	raw       []*jsonschema.Schema
+}
+
+func (c *schemaConverter) schemaRef(js *jsonschema.Schema) *openapi3.SchemaRef {
+	if js == nil {
+		return nil
+	}
+	if !c.openAPI31 {
+		return convertJSONSchemaToSchemaRef(js, c.defs)
+	}
+	c.raw = append(c.raw, js)
+	return &openapi3.SchemaRef{Ref: fmt.Sprintf("%s%d", rawSchemaRefPrefix, len(c.raw)-1)}
+}
+
+// convertJSONSchemaDefToOpenAPI converts a single JSON Schema definition to OpenAPI Schema.
+// Every keyword that OpenAPI 3.0 can represent is preserved; keywords without a 3.0 counterpart
+// are mapped to their closest equivalent (const → single-value enum, examples → example,
+// {"type": "null"} alternatives → nullable).
 func convertJSONSchemaDefToOpenAPI(js *jsonschema.Schema, defs jsonschema.Definitions) *openapi3.Schema {
 	if js == nil {
 		return nil
@@ -174,8 +279,11 @@ func convertJSONSchemaDefToOpenAPI(js *jsonschema.Schema, defs jsonschema.Defini
 		return schema
 	}
 
+	// Convert nullable alternatives first, since they may replace the whole schema
+	convertNullability(js, schema, defs)
+
 	// Convert type
-	if js.Type != "" {
+	if js.Type != "" && js.Type != "null" {
 		var openAPIType string
 		switch js.Type {
 		case "string":
@@ -209,6 +317,13 @@ func convertJSONSchemaDefToOpenAPI(js *jsonschema.Schema, defs jsonschema.Defini
 		schema.Items = convertJSONSchemaToSchemaRef(js.Items, defs)
 	}
 
+	// Convert enum and const
+	if len(js.Enum) > 0 {
+		schema.Enum = js.Enum
+	} else if js.Const != nil {
+		schema.Enum = []any{js.Const}
+	}
+
 	// Convert required fields
 	if len(js.Required) > 0 {
 		schema.Required = js.Required
@@ -233,13 +348,198 @@ func convertJSONSchemaDefToOpenAPI(js *jsonschema.Schema, defs jsonschema.Defini
 		}
 	}
 
+	convertAnnotations(js, schema)
+	convertValidations(js, schema)
+	convertComposition(js, schema, defs)
+
 	return schema
 }
 
-// buildOpenAPIServers builds the servers list from environment configurations
-func buildOpenAPIServers(envs []Env) openapi3.Servers {
+// isNullSchema reports whether s only allows null, i.e. {"type": "null"}.
+func isNullSchema(s *jsonschema.Schema) bool {
+	return s != nil && s.Type == "null" && s.Ref == ""
+}
+
+// withoutNullSchemas returns subs without {"type": "null"} entries and whether any were removed.
+func withoutNullSchemas(subs []*jsonschema.Schema) ([]*jsonschema.Schema, bool) {
+	rest := make([]*jsonschema.Schema, 0, len(subs))
+	found := false
+	for _, sub := range subs {
+		if isNullSchema(sub) {
+			found = true
+			continue
+		}
+		rest = append(rest, sub)
+	}
+	return rest, found
+}
+
+// convertNullability maps JSON Schema nullability to OpenAPI 3.0 "nullable".
+// A oneOf/anyOf with a single non-null alternative (as produced by the "nullable" jsonschema tag)
+// is collapsed into that alternative; $ref alternatives are wrapped in allOf,
+// since OpenAPI 3.0 ignores siblings of $ref.
+func convertNullability(js *jsonschema.Schema, schema *openapi3.Schema, defs jsonschema.Definitions) {
+	if js.Type == "null" {
+		schema.Nullable = true
+	}
+
+	for _, subs := range [][]*jsonschema.Schema{js.OneOf, js.AnyOf} {
+		rest, nullable := withoutNullSchemas(subs)
+		if !nullable || len(rest) != 1 {
+			continue
+		}
+		inner := convertJSONSchemaToSchemaRef(rest[0], defs)
+		if inner.Ref != "" {
+			schema.AllOf = openapi3.SchemaRefs{inner}
+		} else {
+			*schema = *inner.Value
+		}
+		schema.Nullable = true
+		return
+	}
+}
+
+// convertAnnotations converts the annotation keywords (title, description, default, examples, ...).
+func convertAnnotations(js *jsonschema.Schema, schema *openapi3.Schema) {
+	if js.Title != "" {
+		schema.Title = js.Title
+	}
+	if js.Description != "" {
+		schema.Description = js.Description
+	}
+	if js.Format != "" {
+		schema.Format = js.Format
+	}
+	if js.Default != nil {
+		schema.Default = js.Default
+	}
+	if len(js.Examples) > 0 {
+		// OpenAPI 3.0 only supports a single example
+		schema.Example = js.Examples[0]
+	}
+	if js.Deprecated {
+		schema.Deprecated = true
+	}
+	if js.ReadOnly {
+		schema.ReadOnly = true
+	}
+	if js.WriteOnly {
+		schema.WriteOnly = true
+	}
+}
+
+// convertValidations converts the string, numeric, array and object validation keywords.
+func convertValidations(js *jsonschema.Schema, schema *openapi3.Schema) {
+	// String
+	if js.Pattern != "" {
+		schema.Pattern = js.Pattern
+	}
+	if js.MinLength != nil {
+		schema.MinLength = *js.MinLength
+	}
+	if js.MaxLength != nil {
+		schema.MaxLength = js.MaxLength
+	}
+
+	// Number
+	if v, ok := jsonNumberToFloat(js.Minimum); ok {
+		schema.Min = &v
+	}
+	if v, ok := jsonNumberToFloat(js.Maximum); ok {
+		schema.Max = &v
+	}
+	// In JSON Schema 2020-12 exclusiveMinimum/exclusiveMaximum are numbers,
+	// while in OpenAPI 3.0 they are booleans modifying minimum/maximum
+	if v, ok := jsonNumberToFloat(js.ExclusiveMinimum); ok {
+		schema.Min = &v
+		schema.ExclusiveMin = true
+	}
+	if v, ok := jsonNumberToFloat(js.ExclusiveMaximum); ok {
+		schema.Max = &v
+		schema.ExclusiveMax = true
+	}
+	if v, ok := jsonNumberToFloat(js.MultipleOf); ok {
+		schema.MultipleOf = &v
+	}
+
+	// Array
+	if js.MinItems != nil {
+		schema.MinItems = *js.MinItems
+	}
+	if js.MaxItems != nil {
+		schema.MaxItems = js.MaxItems
+	}
+	if js.UniqueItems {
+		schema.UniqueItems = true
+	}
+
+	// Object
+	if js.MinProperties != nil {
+		schema.MinProps = *js.MinProperties
+	}
+	if js.MaxProperties != nil {
+		schema.MaxProps = js.MaxProperties
+	}
+}
+
+// convertComposition converts oneOf, anyOf, allOf and not.
+// Nullable alternatives already collapsed by convertNullability are skipped.
+func convertComposition(js *jsonschema.Schema, schema *openapi3.Schema, defs jsonschema.Definitions) {
+	convertAll := func(subs []*jsonschema.Schema) openapi3.SchemaRefs {
+		rest, nullable := withoutNullSchemas(subs)
+		if nullable {
+			if len(rest) == 1 {
+				return nil
+			}
+			schema.Nullable = true
+		}
+		refs := make(openapi3.SchemaRefs, 0, len(rest))
+		for _, sub := range rest {
+			refs = append(refs, convertJSONSchemaToSchemaRef(sub, defs))
+		}
+		return refs
+	}
+
+	if len(js.OneOf) > 0 {
+		if refs := convertAll(js.OneOf); len(refs) > 0 {
+			schema.OneOf = refs
+		}
+	}
+	if len(js.AnyOf) > 0 {
+		if refs := convertAll(js.AnyOf); len(refs) > 0 {
+			schema.AnyOf = refs
+		}
+	}
+	if len(js.AllOf) > 0 {
+		for _, sub := range js.AllOf {
+			schema.AllOf = append(schema.AllOf, convertJSONSchemaToSchemaRef(sub, defs))
+		}
+	}
+	if js.Not != nil {
+		schema.Not = convertJSONSchemaToSchemaRef(js.Not, defs)
+	}
+}
+
+// jsonNumberToFloat converts a JSON Schema number keyword, reporting false if it is unset.
+func jsonNumberToFloat(n json.Number) (float64, bool) {
+	if n == "" {
+		return 0, false
+	}
+	v, err := n.Float64()
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// buildOpenAPIServers builds the servers list from environment configurations.
+// If version is given, only the servers of that version are included.
+func buildOpenAPIServers(envs []Env, version string) openapi3.Servers {
 	servers := openapi3.Servers{}
 	for _, v := range envs {
+		if version != "" && v.Version != version {
+			continue
+		}
 		servers = append(servers, &openapi3.Server{
 			URL:         v.Domain.Local,
 			Description: fmt.Sprintf("%v at local", v.Version),
@@ -261,8 +561,13 @@ func buildOpenAPIServers(envs []Env) openapi3.Servers {
 
 // collectAndConvertSchemas collects all type definitions and converts them to OpenAPI schemas.
 // It handles name collisions by renaming conflicting types to qualified names.
-func (e *endpoints) collectAndConvertSchemas() (jsonschema.Definitions, openapi3.Schemas, map[string]string) {
+// Names are resolved across every API so that they are stable between documents, but only
+// the definitions referenced by the APIs included in version and frontend are returned.
+func (e *endpoints) collectAndConvertSchemas(version, frontend string) (jsonschema.Definitions, openapi3.Schemas, map[string]string) {
 	allDefs, renames := mergeDefs(e.collectAllDefs())
+	if version != "" || frontend != "" {
+		allDefs = referencedDefs(allDefs, e.collectDefs(e.filterAPI(version, frontend)), renames)
+	}
 
 	openAPISchemas := make(openapi3.Schemas)
 	for name, def := range allDefs {
@@ -326,14 +631,15 @@ func normalizePathAndExtractParameters(apiPath string, description string) (stri
 	return path, parameters
 }
 
-// generateSchemaRef generates an OpenAPI schema reference from a Go type.
+// generateSchemaRef generates an OpenAPI schema reference from a Go type,
+// reflected as a request body if request is true.
 // It applies name collision renames via the renames map.
-func (e *endpoints) generateSchemaRef(typ any, allDefs jsonschema.Definitions, renames map[string]string) *openapi3.SchemaRef {
+func (e *endpoints) generateSchemaRef(typ any, request bool, conv *schemaConverter, renames map[string]string) *openapi3.SchemaRef {
 	if typ == nil {
 		return nil
 	}
 
-	schema, _ := reflectType(typ)
+	schema, _ := reflectTypeAs(typ, request)
 	rewriteRefs(schema, renames)
 
 	if schema.Ref != "" {
@@ -341,7 +647,41 @@ func (e *endpoints) generateSchemaRef(typ any, allDefs jsonschema.Definitions, r
 		return &openapi3.SchemaRef{Ref: ref}
 	}
 
-	return &openapi3.SchemaRef{Value: convertJSONSchemaDefToOpenAPI(schema, allDefs)}
+	return conv.schemaRef(schema)
+}
+
+// legacyAuthSchemeName is the security scheme applied to APIs without an AuthSchema
+// when OpenApiGeneratorConfig.AuthHeader is set.
+const legacyAuthSchemeName = "auth"
+
+// securitySchemeName returns the key of a in components.securitySchemes.
+func (a AuthSchema) securitySchemeName() string {
+	return fmt.Sprintf("%s-%s", a.Type, a.Header)
+}
+
+// securityScheme returns the OpenAPI security scheme for a.
+// A Bearer schema sent in the Authorization header becomes an HTTP bearer scheme;
+// any other schema becomes an apiKey scheme in its header.
+func (a AuthSchema) securityScheme() *openapi3.SecurityScheme {
+	if strings.EqualFold(a.Type, "Bearer") && strings.EqualFold(a.Header, "Authorization") {
+		return &openapi3.SecurityScheme{Type: "http", Scheme: "bearer"}
+	}
+	return &openapi3.SecurityScheme{Type: "apiKey", Name: a.Header, In: openapi3.ParameterInHeader}
+}
+
+// securitySchemeFor returns the name and definition of the security scheme api requires,
+// or "" and nil for a public endpoint.
+func securitySchemeFor(api API, config OpenApiGeneratorConfig) (string, *openapi3.SecurityScheme) {
+	if api.AuthSchema.isNone() {
+		return "", nil
+	}
+	if api.AuthSchema != (AuthSchema{}) {
+		return api.AuthSchema.securitySchemeName(), api.AuthSchema.securityScheme()
+	}
+	if config.AuthHeader != "" {
+		return legacyAuthSchemeName, &openapi3.SecurityScheme{Type: "apiKey", Name: config.AuthHeader, In: openapi3.ParameterInHeader}
+	}
+	return "", nil
 }
 
 // buildOperation builds an OpenAPI operation from an API definition
@@ -352,12 +692,16 @@ func buildOperation(api API, path string, parameters openapi3.Parameters, reques
 			tags = append(tags, c.Tag)
 		}
 	}
+	tags = append(tags, api.Tags...)
+
+	status := api.status()
 
 	var responseContent openapi3.Content
-	if responseSchemaRef != nil {
+	if responseSchemaRef != nil && statusHasBody(status) {
 		responseContent = openapi3.Content{
 			"application/json": &openapi3.MediaType{
-				Schema: responseSchemaRef,
+				Schema:  responseSchemaRef,
+				Example: api.Example,
 			},
 		}
 	}
@@ -370,7 +714,7 @@ func buildOperation(api API, path string, parameters openapi3.Parameters, reques
 		Parameters:  parameters,
 		RequestBody: nil,
 		Responses: openapi3.NewResponses(
-			openapi3.WithStatus(200, &openapi3.ResponseRef{
+			openapi3.WithStatus(status, &openapi3.ResponseRef{
 				Ref: "",
 				Value: &openapi3.Response{
 					Description: &description,
@@ -380,15 +724,27 @@ func buildOperation(api API, path string, parameters openapi3.Parameters, reques
 				},
 			}),
 		),
-		Callbacks:  nil,
-		Deprecated: false,
-		Security: &openapi3.SecurityRequirements{
-			{"auth": []string{}},
-		},
+		Callbacks:    nil,
+		Deprecated:   api.Deprecated,
+		Security:     &openapi3.SecurityRequirements{},
 		Servers:      nil,
 		ExternalDocs: nil,
 	}
 
+	// Public endpoints keep an empty requirement so that they stay public even if
+	// a document-wide requirement is added later.
+	if name, _ := securitySchemeFor(api, config); name != "" {
+		operation.Security = &openapi3.SecurityRequirements{
+			{name: []string{}},
+		}
+	}
+
+	if len(api.Metadata) > 0 {
+		operation.Extensions = map[string]any{
+			"x-metadata": api.Metadata,
+		}
+	}
+
 	// Set request body if Request is provided and method requires body
 	if requestSchemaRef != nil && (api.Method == http.MethodPost || api.Method == http.MethodPut || api.Method == http.MethodPatch) {
 		operation.RequestBody = &openapi3.RequestBodyRef{
@@ -406,21 +762,131 @@ func buildOperation(api API, path string, parameters openapi3.Parameters, reques
 	return operation
 }
 
+// addErrorResponses adds the declared error responses to operation.
+// Errors sharing a status are combined into one response whose body is a oneOf of their bodies.
+func (e *endpoints) addErrorResponses(operation *openapi3.Operation, errs []ErrorResponse, conv *schemaConverter, renames map[string]string) {
+	var statuses []int
+	byStatus := map[int][]ErrorResponse{}
+	for _, er := range errs {
+		if _, ok := byStatus[er.Status]; !ok {
+			statuses = append(statuses, er.Status)
+		}
+		byStatus[er.Status] = append(byStatus[er.Status], er)
+	}
+
+	for _, status := range statuses {
+		// A single error from the catalog refers to the shared response component
+		if errs := byStatus[status]; len(errs) == 1 && errs[0].Code != "" {
+			if _, ok := e.errorCode(errs[0].Code); ok {
+				operation.Responses.Set(strconv.Itoa(status), &openapi3.ResponseRef{Ref: "#/components/responses/" + errs[0].Code})
+				continue
+			}
+		}
+
+		var descriptions []string
+		var bodies openapi3.SchemaRefs
+		mediaType := "application/json"
+		seen := map[reflect.Type]bool{}
+		for _, er := range byStatus[status] {
+			if er.Desc != "" {
+				descriptions = append(descriptions, er.Desc)
+			}
+			if er.Body == nil || seen[reflect.TypeOf(er.Body)] {
+				continue
+			}
+			seen[reflect.TypeOf(er.Body)] = true
+			bodies = append(bodies, e.generateSchemaRef(er.Body, false, conv, renames))
+			if isProblemType(er.Body) {
+				mediaType = MIMEApplicationProblemJSON
+			}
+		}
+
+		description := strings.Join(descriptions, "\n")
+		if description == "" {
+			description = http.StatusText(status)
+		}
+		response := &openapi3.Response{Description: &description}
+		switch len(bodies) {
+		case 0:
+		case 1:
+			response.Content = openapi3.NewContentWithSchemaRef(bodies[0], []string{mediaType})
+		default:
+			response.Content = openapi3.NewContentWithSchemaRef(&openapi3.SchemaRef{
+				Value: &openapi3.Schema{OneOf: bodies},
+			}, []string{mediaType})
+		}
+		operation.AddResponse(status, response)
+	}
+}
+
+// errorCodeResponses builds a response component for each error in the catalog.
+// The body is the Problem schema with code and status narrowed to the error.
+func (e *endpoints) errorCodeResponses(conv *schemaConverter, renames map[string]string) openapi3.ResponseBodies {
+	if len(e.errorCodes) == 0 {
+		return nil
+	}
+	problemRef := e.generateSchemaRef(Problem{}, false, conv, renames)
+
+	responses := openapi3.ResponseBodies{}
+	for _, c := range e.errorCodes {
+		description := c.Title
+		if description == "" {
+			description = http.StatusText(c.Status)
+		}
+		narrowed := openapi3.NewObjectSchema().
+			WithProperty("code", openapi3.NewStringSchema().WithEnum(c.Code)).
+			WithProperty("status", openapi3.NewIntegerSchema().WithEnum(c.Status))
+		schema := &openapi3.SchemaRef{
+			Value: &openapi3.Schema{AllOf: openapi3.SchemaRefs{problemRef, {Value: narrowed}}},
+		}
+		responses[c.Code] = &openapi3.ResponseRef{
+			Value: &openapi3.Response{
+				Description: &description,
+				Content:     openapi3.NewContentWithSchemaRef(schema, []string{MIMEApplicationProblemJSON}),
+			},
+		}
+	}
+	return responses
+}
+
 func (e *endpoints) generateOpenApiSchema(config OpenApiGeneratorConfig) (openapi3.T, error) {
-	servers := buildOpenAPIServers(e.env)
+	schema, _, err := e.buildOpenApiSchema(config)
+	return schema, err
+}
+
+// buildOpenApiSchema builds the OpenAPI document along with the schemaConverter used for it.
+// For OpenAPI 3.1 the converter holds the raw JSON Schemas that openAPI31Document substitutes
+// into the marshaled document.
+func (e *endpoints) buildOpenApiSchema(config OpenApiGeneratorConfig) (openapi3.T, *schemaConverter, error) {
+	servers := buildOpenAPIServers(e.env, config.Version)
 	description := "Generated by endpoints-go"
 
-	allDefs, openAPISchemas, renames := e.collectAndConvertSchemas()
+	allDefs, openAPISchemas, renames := e.collectAndConvertSchemas(config.Version, config.Frontend)
+	conv := &schemaConverter{defs: allDefs, openAPI31: config.openApiVersion() == OpenApiVersion31}
+	if conv.openAPI31 {
+		openAPISchemas = openapi3.Schemas{}
+	}
+
+	apis := e.filterAPI(config.Version, config.Frontend)
 
 	paths := openapi3.Paths{}
-	for _, api := range e.api {
+	securitySchemes := openapi3.SecuritySchemes{}
+	for _, api := range apis {
+		if name, scheme := securitySchemeFor(api, config); name != "" {
+			securitySchemes[name] = &openapi3.SecuritySchemeRef{Value: scheme}
+		}
 
 		path, parameters := normalizePathAndExtractParameters(api.Path, description)
+		parameters = mergeParameters(parameters, requestParameters(api.Request, conv, renames, description))
 
-		requestSchemaRef := e.generateSchemaRef(api.Request, allDefs, renames)
-		responseSchemaRef := e.generateSchemaRef(api.Response, allDefs, renames)
+		var requestSchemaRef *openapi3.SchemaRef
+		if api.hasRequestBody() {
+			requestSchemaRef = e.generateSchemaRef(api.Request, true, conv, renames)
+		}
+		responseSchemaRef := e.generateSchemaRef(api.Response, false, conv, renames)
 
 		operation := buildOperation(api, path, parameters, requestSchemaRef, responseSchemaRef, config, description)
+		e.addErrorResponses(&operation, api.Errors, conv, renames)
 
 		item := &openapi3.PathItem{}
 		if paths.Value(path) != nil {
@@ -450,25 +916,29 @@ func (e *endpoints) generateOpenApiSchema(config OpenApiGeneratorConfig) (openap
 			Description: c.Tag,
 		})
 	}
+	for _, api := range apis {
+		for _, t := range api.Tags {
+			if tags.Get(t) == nil {
+				tags = append(tags, &openapi3.Tag{
+					Name:        t,
+					Description: t,
+				})
+			}
+		}
+	}
 
 	schema := openapi3.T{
 		Extensions: nil,
-		OpenAPI:    "3.0.0",
+		OpenAPI:    config.openApiVersion(),
 		Components: &openapi3.Components{
-			Schemas: openAPISchemas,
-			SecuritySchemes: openapi3.SecuritySchemes{
-				"auth": &openapi3.SecuritySchemeRef{
-					Value: &openapi3.SecurityScheme{
-						Type: "apiKey",
-						Name: config.AuthHeader,
-						In:   "header",
-					},
-				},
-			},
+			Schemas:         openAPISchemas,
+			SecuritySchemes: securitySchemes,
+			Responses:       e.errorCodeResponses(conv, renames),
 		},
 		Info: &openapi3.Info{
 			Title:       config.Title,
 			Description: config.Desc,
+			Version:     config.Version,
 		},
 		Paths:    &paths,
 		Security: openapi3.SecurityRequirements{},
@@ -476,16 +946,29 @@ func (e *endpoints) generateOpenApiSchema(config OpenApiGeneratorConfig) (openap
 		Tags:     tags,
 	}
 
-	return schema, nil
+	return schema, conv, nil
 }
 
-func (e *endpoints) generateOpenApiJson(file io.Writer, config OpenApiGeneratorConfig) error {
-	schema, err := e.generateOpenApiSchema(config)
+// generateOpenApiDocument returns the OpenAPI document marshaled as JSON.
+func (e *endpoints) generateOpenApiDocument(config OpenApiGeneratorConfig) ([]byte, error) {
+	schema, conv, err := e.buildOpenApiSchema(config)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	bs, err := schema.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	if conv.openAPI31 {
+		return openAPI31Document(bs, conv)
+	}
+	return bs, nil
+}
+
+func (e *endpoints) generateOpenApiJson(file io.Writer, config OpenApiGeneratorConfig) error {
+	bs, err := e.generateOpenApiDocument(config)
 	if err != nil {
 		return err
 	}
@@ -498,12 +981,7 @@ func (e *endpoints) generateOpenApiJson(file io.Writer, config OpenApiGeneratorC
 }
 
 func (e *endpoints) generateOpenApiYaml(file io.Writer, config OpenApiGeneratorConfig) error {
-	schema, err := e.generateOpenApiSchema(config)
-	if err != nil {
-		return err
-	}
-
-	jbs, err := schema.MarshalJSON()
+	jbs, err := e.generateOpenApiDocument(config)
 	if err != nil {
 		return err
 	}
@@ -534,35 +1012,76 @@ type schemaStruct struct {
 	Items *jsonschema.Schema `json:"items,omitempty"`
 }
 
+// generatedParams は、リクエストの型のparam, query, headerタグから生成されたパラメータのスキーマ
+type generatedParams struct {
+	Path   *jsonschema.Schema `json:"path,omitempty"`
+	Query  *jsonschema.Schema `json:"query,omitempty"`
+	Header *jsonschema.Schema `json:"header,omitempty"`
+}
+
 type generatedApi struct {
-	Path       string        `json:"path"`
-	Desc       string        `json:"desc"`
-	Method     string        `json:"method"`
-	AuthSchema AuthSchema    `json:"authSchema"`
-	Request    *schemaStruct `json:"request"`
-	Response   *schemaStruct `json:"response"`
+	Path       string            `json:"path"`
+	Desc       string            `json:"desc"`
+	Method     string            `json:"method"`
+	AuthSchema AuthSchema        `json:"authSchema"`
+	Request    *schemaStruct     `json:"request"`
+	Response   *schemaStruct     `json:"response"`
+	Params     *generatedParams  `json:"params,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+	Deprecated bool              `json:"deprecated,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	// 省略された場合は200
+	Status  int              `json:"status,omitempty"`
+	Errors  []generatedError `json:"errors,omitempty"`
+	Example any              `json:"example,omitempty"`
+}
+
+type generatedError struct {
+	Status int           `json:"status"`
+	Code   string        `json:"code,omitempty"`
+	Desc   string        `json:"desc"`
+	Body   *schemaStruct `json:"body"`
+}
+
+// generatedErrorCode は、.endpoints.jsonの$errorsに出力されるエラーカタログの要素
+type generatedErrorCode struct {
+	Code   string `json:"code"`
+	Status int    `json:"status"`
+	Title  string `json:"title"`
+	Type   string `json:"type,omitempty"`
+}
+
+func (e *endpoints) generateErrorCodeList() []generatedErrorCode {
+	codes := make([]generatedErrorCode, 0, len(e.errorCodes))
+	for _, c := range e.errorCodes {
+		codes = append(codes, generatedErrorCode{Code: c.Code, Status: c.Status, Title: c.Title, Type: c.Type})
+	}
+	return codes
 }
 
 func (e *endpoints) generateAPIList(version string, renames map[string]string) *orderedmap.OrderedMap {
 	apis := orderedmap.New()
-	for _, v := range e.api {
-		// v.Versionsが定義されていない場合は全てのバージョンに含まれるものとして扱う
-		if len(v.Versions) == 0 || v.Versions.Includes(version) {
-			apis.Set(v.Name, v.generatedApi(renames))
-		}
+	for _, v := range e.filterAPI(version, "") {
+		apis.Set(v.Name, v.generatedApi(renames))
 	}
 	return apis
 }
 
 func (e *endpoints) generateAPIListByFrontend(version, frontend string, renames map[string]string) *orderedmap.OrderedMap {
 	apis := orderedmap.New()
+	for _, v := range e.filterAPI(version, frontend) {
+		apis.Set(v.Name, v.generatedApi(renames))
+	}
+	return apis
+}
+
+// filterAPI は、versionとfrontendに含まれるHiddenでないAPIを返す
+// versionやfrontendが空文字列の場合、その条件では絞り込まない
+func (e *endpoints) filterAPI(version, frontend string) []API {
+	var apis []API
 	for _, v := range e.api {
-		// v.Versionsが定義されていない場合は全てのバージョンに含まれるものとして扱う
-		if len(v.Versions) == 0 || v.Versions.Includes(version) {
-			// v.Targetsが定義されていない場合は全てのフロントエンドに含まれるものとして扱う
-			if len(v.Frontends) == 0 || v.Frontends.Includes(frontend) {
-				apis.Set(v.Name, v.generatedApi(renames))
-			}
+		if v.includedIn(version, frontend) {
+			apis = append(apis, v)
 		}
 	}
 	return apis
@@ -580,6 +1099,45 @@ type Domain struct {
 	Prod     string `json:"prod"`
 }
 
+// Stage は、Domainのどの環境のURLを使うかを表す
+type Stage string
+
+const (
+	StageLocal    Stage = "local"
+	StageLocalDev Stage = "localDev"
+	StageDev      Stage = "dev"
+	StageProd     Stage = "prod"
+)
+
+// URL は、stageに対応するURLを返す
+// 未知のstageの場合は空文字列を返す
+func (d Domain) URL(stage Stage) string {
+	switch stage {
+	case StageLocal:
+		return d.Local
+	case StageLocalDev:
+		return d.LocalDev
+	case StageDev:
+		return d.Dev
+	case StageProd:
+		return d.Prod
+	default:
+		return ""
+	}
+}
+
+// ErrorResponse は、エンドポイントが返しうるエラーレスポンスを表す
+type ErrorResponse struct {
+	// ステータスコード e.g. http.StatusNotFound
+	Status int
+	// レスポンスボディの型. nilの場合、bodyを返さないものとみなす
+	Body any
+	// どのような場合に返されるエラーか
+	Desc string
+	// エラーカタログに登録されたエラーコード. ErrorCode.Responseで生成した場合に設定される
+	Code string
+}
+
 type AuthSchema struct {
 	Type   string `json:"type"`
 	Header string `json:"header"`
@@ -599,6 +1157,28 @@ func NewApiKeyAuthSchema() AuthSchema {
 	}
 }
 
+// authSchemaTypeNone は、NewNoneAuthSchemaのType
+const authSchemaTypeNone = "None"
+
+// NewNoneAuthSchema は、認証不要なエンドポイントを表すAuthSchemaを返す
+// GroupOptionsのAuthSchemaや、OpenApiGeneratorConfig.AuthHeaderを引き継がない
+// .endpoints.jsonには、AuthSchemaが指定されていない場合と同じく出力される
+func NewNoneAuthSchema() AuthSchema {
+	return AuthSchema{Type: authSchemaTypeNone}
+}
+
+func (a AuthSchema) isNone() bool {
+	return a.Type == authSchemaTypeNone
+}
+
+// published は、.endpoints.jsonなどに出力するAuthSchemaを返す. 認証不要な場合はゼロ値を返す
+func (a AuthSchema) published() AuthSchema {
+	if a.isNone() {
+		return AuthSchema{}
+	}
+	return a
+}
+
 type API struct {
 	Name       string
 	Path       string
@@ -615,14 +1195,30 @@ type API struct {
 	// 対象とするフロントエンド e.g. "guest", "manager", "admin"
 	// 指定がない場合、すべてのフロントエンド向けの.endpoints.jsonに含むものとみなす
 	Frontends Frontends
+
+	// OpenAPIのtagとして追加される
+	Tags []string
+	// trueの場合、非推奨のエンドポイントとして出力される
+	Deprecated bool
+	// trueの場合、.endpoints.jsonやOpenAPIに出力されない
+	Hidden bool
+	// 任意の追加情報
+	Metadata map[string]string
+	// レスポンスのステータスコード
+	// 指定がない場合、200とみなす
+	Status int
+	// エンドポイントが返しうるエラーレスポンス
+	Errors []ErrorResponse
+	// レスポンスの例. OpenAPIのexampleとして出力され、モックサーバーが返す
+	Example any
 }
 
 func (v API) generatedApi(renames map[string]string) generatedApi {
-	build := func(typ any) *schemaStruct {
+	build := func(typ any, request bool) *schemaStruct {
 		if typ == nil {
 			return nil
 		}
-		s, _ := reflectType(typ)
+		s, _ := reflectTypeAs(typ, request)
 		ref := applyRenameToRef(s.Ref, renames)
 		items := s.Items
 		if items != nil {
@@ -630,16 +1226,76 @@ func (v API) generatedApi(renames map[string]string) generatedApi {
 		}
 		return &schemaStruct{Ref: ref, Type: s.Type, Items: items}
 	}
+	var request any
+	if v.hasRequestBody() {
+		request = v.Request
+	}
+	var errs []generatedError
+	for _, er := range v.Errors {
+		errs = append(errs, generatedError{Status: er.Status, Code: er.Code, Desc: er.Desc, Body: build(er.Body, false)})
+	}
+	var params *generatedParams
+	if v.Request != nil {
+		path := parameterSchema(v.Request, openapi3.ParameterInPath, renames)
+		query := parameterSchema(v.Request, openapi3.ParameterInQuery, renames)
+		header := parameterSchema(v.Request, openapi3.ParameterInHeader, renames)
+		if path != nil || query != nil || header != nil {
+			params = &generatedParams{Path: path, Query: query, Header: header}
+		}
+	}
 	return generatedApi{
 		Path:       strings.TrimPrefix(v.Path, "/"),
 		Desc:       v.Desc,
 		Method:     v.Method,
-		AuthSchema: v.AuthSchema,
-		Request:    build(v.Request),
-		Response:   build(v.Response),
+		AuthSchema: v.AuthSchema.published(),
+		Request:    build(request, true),
+		Response:   build(v.Response, false),
+		Params:     params,
+		Tags:       v.Tags,
+		Deprecated: v.Deprecated,
+		Metadata:   v.Metadata,
+		Status:     v.Status,
+		Errors:     errs,
+		Example:    v.Example,
 	}
 }
 
+// status は、APIが返すレスポンスのステータスコードを返す
+func (v API) status() int {
+	if v.Status != 0 {
+		return v.Status
+	}
+	return http.StatusOK
+}
+
+// statusHasBody は、statusのレスポンスがbodyをもちうるかどうかを返す
+func statusHasBody(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK
+}
+
+// includedIn は、APIがversionとfrontendに含まれるかどうかを返す
+// versionやfrontendが空文字列の場合、その条件では絞り込まない
+func (v API) includedIn(version, frontend string) bool {
+	if v.Hidden {
+		return false
+	}
+	// v.Versionsが定義されていない場合は全てのバージョンに含まれるものとして扱う
+	if version != "" && len(v.Versions) > 0 && !v.Versions.Includes(version) {
+		return false
+	}
+	// v.Frontendsが定義されていない場合は全てのフロントエンドに含まれるものとして扱う
+	if frontend != "" && len(v.Frontends) > 0 && !v.Frontends.Includes(frontend) {
+		return false
+	}
+	return true
+}
+
+// hasRequestBody は、RequestがJSONのリクエストボディとして送られるかどうかを返す
+// GETのRequestや、param, query, headerタグのフィールドしかもたないRequestはボディをもたないものとみなす
+func (v API) hasRequestBody() bool {
+	return v.Request != nil && v.Method != http.MethodGet && hasBodyFields(v.Request)
+}
+
 type Versions []string
 
 // 引数として与えられたversionが含まれているかどうかを返す
@@ -667,42 +1323,125 @@ func (fs Frontends) Includes(target string) bool {
 // preventing name collisions even within a single Reflect call.
 // Returns the schema and a map of qualifiedName → shortName (t.Name()) for rename computation.
 func reflectType(typ any) (*jsonschema.Schema, map[string]string) {
+	return reflectTypeAs(typ, false)
+}
+
+// reflectRequestType reflects typ like reflectType, excluding the fields bound from path, query
+// or header parameters, since they are not part of the JSON request body.
+// Responses keep those fields, as c.JSON serializes them.
+func reflectRequestType(typ any) (*jsonschema.Schema, map[string]string) {
+	return reflectTypeAs(typ, true)
+}
+
+// reflectTypeAs reflects typ as a request body if request is true, and as a response body otherwise.
+func reflectTypeAs(typ any, request bool) (*jsonschema.Schema, map[string]string) {
 	shortNames := make(map[string]string)
-	r := &jsonschema.Reflector{
+	types := make(map[string]reflect.Type)
+	r := newReflector(shortNames, types)
+	schema := r.Reflect(typ)
+	top := reflect.TypeOf(typ)
+	for top != nil && top.Kind() == reflect.Ptr {
+		top = top.Elem()
+	}
+	for name, def := range schema.Definitions {
+		if t, ok := types[name]; ok {
+			applyValidateTags(def, t, "json")
+			// Only the request type itself is bound from path, query and header parameters
+			if request && t == top {
+				stripParameterFields(def, t)
+			}
+		}
+	}
+	if t := reflect.TypeOf(typ); t != nil && schema.Ref == "" {
+		applyValidateTags(schema, t, "json")
+		if request {
+			stripParameterFields(schema, t)
+		}
+	}
+	return schema, shortNames
+}
+
+// newReflector returns a Reflector that names $defs keys by qualifiedTypeName.
+// Every named type is recorded in shortNames (qualifiedName → t.Name()) and types (qualifiedName → t).
+func newReflector(shortNames map[string]string, types map[string]reflect.Type) *jsonschema.Reflector {
+	return &jsonschema.Reflector{
 		Namer: func(t reflect.Type) string {
 			if t.PkgPath() == "" {
 				return t.Name()
 			}
 			qual := qualifiedTypeName(t)
 			shortNames[qual] = t.Name()
+			types[qual] = t
 			return qual
 		},
 	}
-	return r.Reflect(typ), shortNames
 }
 
 // reflectResult holds a reflected schema and its qualifiedName → shortName mapping.
 type reflectResult struct {
 	schema     *jsonschema.Schema
 	shortNames map[string]string // qualifiedName → t.Name()
+	// request is true when schema was reflected as a request body, without its parameter fields
+	request bool
 }
 
-// collectAllDefs reflects all API request/response types.
+// collectAllDefs reflects all API request/response types, skipping hidden APIs.
 func (e *endpoints) collectAllDefs() []reflectResult {
+	return e.collectDefs(e.api)
+}
+
+// collectDefs reflects the request/response types of apis, skipping hidden APIs.
+func (e *endpoints) collectDefs(apis []API) []reflectResult {
 	var results []reflectResult
-	for _, api := range e.api {
+	for _, api := range apis {
+		if api.Hidden {
+			continue
+		}
 		if api.Request != nil {
-			s, shortNames := reflectType(api.Request)
-			results = append(results, reflectResult{schema: s, shortNames: shortNames})
+			s, shortNames := reflectRequestType(api.Request)
+			// Requests without a body only contribute the types referenced by their parameters
+			if !api.hasRequestBody() && s.Ref != "" {
+				delete(s.Definitions, strings.TrimPrefix(s.Ref, "#/$defs/"))
+			}
+			results = append(results, reflectResult{schema: s, shortNames: shortNames, request: true})
 		}
 		if api.Response != nil {
 			s, shortNames := reflectType(api.Response)
 			results = append(results, reflectResult{schema: s, shortNames: shortNames})
 		}
+		for _, er := range api.Errors {
+			if er.Body != nil {
+				s, shortNames := reflectType(er.Body)
+				results = append(results, reflectResult{schema: s, shortNames: shortNames})
+			}
+		}
+	}
+	// The error catalog is rendered with the Problem schema
+	if len(e.errorCodes) > 0 {
+		s, shortNames := reflectType(Problem{})
+		results = append(results, reflectResult{schema: s, shortNames: shortNames})
 	}
 	return results
 }
 
+// referencedDefs returns the subset of merged whose definitions appear in results,
+// using renames (qualifiedName → finalName) to map the reflected names onto merged.
+func referencedDefs(merged jsonschema.Definitions, results []reflectResult, renames map[string]string) jsonschema.Definitions {
+	referenced := make(jsonschema.Definitions)
+	for _, r := range results {
+		for q := range r.schema.Definitions {
+			name := q
+			if final, ok := renames[q]; ok {
+				name = final
+			}
+			if def, ok := merged[name]; ok {
+				referenced[name] = def
+			}
+		}
+	}
+	return referenced
+}
+
 // mergeDefs merges all reflected schemas, resolving name collisions.
 // Types whose short name is unique across all schemas keep the short name;
 // types that collide keep their qualified name.
@@ -711,9 +1450,15 @@ func mergeDefs(results []reflectResult) (jsonschema.Definitions, map[string]stri
 	// Collect all defs and build a unified qualifiedName → shortName mapping
 	allDefs := make(jsonschema.Definitions)
 	allShortNames := make(map[string]string)
+	fromRequest := make(map[string]bool)
 	for _, r := range results {
 		for k, v := range r.schema.Definitions {
+			// A type also used as a response keeps its parameter fields, since they are serialized
+			if _, exists := allDefs[k]; exists && r.request && !fromRequest[k] {
+				continue
+			}
 			allDefs[k] = v
+			fromRequest[k] = r.request
 		}
 		for q, short := range r.shortNames {
 			allShortNames[q] = short
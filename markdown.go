@@ -0,0 +1,373 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
+)
+
+// MarkdownGeneratorConfig は、EchoWrapper.GenerateMarkdownの設定
+type MarkdownGeneratorConfig struct {
+	// 見出し. 指定がない場合は"API Reference"
+	Title string
+	// pathのprefixごとにエンドポイントをまとめる見出し
+	// 該当しないエンドポイントは、Desc.Tagsの最初のtag、それもなければpathの最初の要素 ("samples"など) でまとめられる
+	TagsByPrefix []struct {
+		Prefix string
+		Tag    string
+	}
+}
+
+// markdownStages are the stages curl examples are written for, in order.
+func markdownStages() []Stage {
+	return []Stage{StageLocal, StageLocalDev, StageDev, StageProd}
+}
+
+// generateMarkdown は、登録されたエンドポイントのリファレンスをMarkdownで生成する
+func (e *endpoints) generateMarkdown(config MarkdownGeneratorConfig) ([]byte, error) {
+	merged, renames := mergeDefs(e.collectAllDefs())
+
+	var groups []string
+	apis := map[string][]API{}
+	for _, api := range e.filterAPI("", "") {
+		group := markdownGroup(api, config)
+		if _, ok := apis[group]; !ok {
+			groups = append(groups, group)
+		}
+		apis[group] = append(apis[group], api)
+	}
+
+	title := config.Title
+	if title == "" {
+		title = "API Reference"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("<!-- Code generated by endpoints-go. DO NOT EDIT. -->\n\n")
+	for _, group := range groups {
+		fmt.Fprintf(&b, "- [%s](#%s)\n", group, markdownAnchor(group))
+		for _, api := range apis[group] {
+			fmt.Fprintf(&b, "  - [%s](#%s)\n", api.Name, markdownAnchor(api.Name))
+		}
+	}
+	if len(merged) > 0 {
+		b.WriteString("- [Types](#types)\n")
+	}
+
+	for _, group := range groups {
+		fmt.Fprintf(&b, "\n## %s\n", group)
+		for _, api := range apis[group] {
+			e.writeMarkdownAPI(&b, api, merged, renames)
+		}
+	}
+
+	if len(merged) > 0 {
+		b.WriteString("\n## Types\n")
+		names := make([]string, 0, len(merged))
+		for name := range merged {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			s := merged[name]
+			fmt.Fprintf(&b, "\n### %s\n\n", name)
+			if s.Description != "" {
+				fmt.Fprintf(&b, "%s\n\n", s.Description)
+			}
+			writeMarkdownSchema(&b, s)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+func (e *endpoints) generateMarkdownFile(filename string, config MarkdownGeneratorConfig) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	bs, err := e.generateMarkdown(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, bs, 0o644)
+}
+
+// markdownGroup returns the heading api is listed under.
+func markdownGroup(api API, config MarkdownGeneratorConfig) string {
+	for _, c := range config.TagsByPrefix {
+		if strings.HasPrefix(api.Path, c.Prefix) {
+			return c.Tag
+		}
+	}
+	if len(api.Tags) > 0 {
+		return api.Tags[0]
+	}
+	path, _, _ := strings.Cut(strings.TrimPrefix(api.Path, "/"), "?")
+	if first, _, _ := strings.Cut(path, "/"); first != "" {
+		return first
+	}
+	return "default"
+}
+
+func (e *endpoints) writeMarkdownAPI(b *bytes.Buffer, api API, defs jsonschema.Definitions, renames map[string]string) {
+	path := "/" + strings.TrimPrefix(api.Path, "/")
+	fmt.Fprintf(b, "\n### %s\n\n", api.Name)
+	fmt.Fprintf(b, "`%s %s`\n\n", api.Method, path)
+	if api.Deprecated {
+		b.WriteString("> **Deprecated**\n\n")
+	}
+	if api.Desc != "" {
+		fmt.Fprintf(b, "%s\n\n", api.Desc)
+	}
+
+	auth := "none"
+	if a := api.AuthSchema.published(); a.Type != "" {
+		auth = fmt.Sprintf("%s (`%s`)", a.Type, a.Header)
+	}
+	versions, frontends := "all", "all"
+	if len(api.Versions) > 0 {
+		versions = strings.Join(api.Versions, ", ")
+	}
+	if len(api.Frontends) > 0 {
+		frontends = strings.Join(api.Frontends, ", ")
+	}
+	b.WriteString("| | |\n| --- | --- |\n")
+	fmt.Fprintf(b, "| Name | `%s` |\n", api.Name)
+	fmt.Fprintf(b, "| Auth | %s |\n", auth)
+	fmt.Fprintf(b, "| Versions | %s |\n", versions)
+	fmt.Fprintf(b, "| Frontends | %s |\n", frontends)
+	fmt.Fprintf(b, "| Status | %d |\n", api.status())
+
+	if api.Request != nil {
+		writeMarkdownParameters(b, api.Request, renames)
+	}
+	if api.hasRequestBody() {
+		b.WriteString("\n#### Request\n\n")
+		writeMarkdownSchemaOf(b, api.Request, true, defs, renames)
+	}
+	if api.Response != nil && statusHasBody(api.status()) {
+		b.WriteString("\n#### Response\n\n")
+		writeMarkdownSchemaOf(b, api.Response, false, defs, renames)
+	}
+	if len(api.Errors) > 0 {
+		b.WriteString("\n#### Errors\n\n| Status | Code | Description |\n| --- | --- | --- |\n")
+		for _, er := range api.Errors {
+			code := ""
+			if er.Code != "" {
+				code = "`" + er.Code + "`"
+			}
+			fmt.Fprintf(b, "| %d | %s | %s |\n", er.Status, code, markdownCell(er.Desc))
+		}
+	}
+
+	e.writeMarkdownCurl(b, api, defs, renames)
+}
+
+func writeMarkdownParameters(b *bytes.Buffer, req any, renames map[string]string) {
+	var rows []string
+	for _, in := range []string{openapi3.ParameterInPath, openapi3.ParameterInQuery, openapi3.ParameterInHeader} {
+		s := parameterSchema(req, in, renames)
+		if s == nil {
+			continue
+		}
+		for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			rows = append(rows, fmt.Sprintf("| `%s` | %s | %s | %s | %s |",
+				pair.Key, in, markdownType(pair.Value), markdownRequired(s, pair.Key), markdownCell(pair.Value.Description)))
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	b.WriteString("\n#### Parameters\n\n| Name | In | Type | Required | Description |\n| --- | --- | --- | --- | --- |\n")
+	for _, row := range rows {
+		b.WriteString(row + "\n")
+	}
+}
+
+// writeMarkdownSchemaOf writes the type of the Go value typ (a request body if request is true),
+// and the fields of the $defs it refers to, so that the request and response can be read in place.
+func writeMarkdownSchemaOf(b *bytes.Buffer, typ any, request bool, defs jsonschema.Definitions, renames map[string]string) {
+	s := markdownSchemaOf(typ, request, renames)
+	fmt.Fprintf(b, "Type: %s\n", markdownType(s))
+	if def, ok := defs[strings.TrimPrefix(s.Ref, "#/$defs/")]; ok && s.Ref != "" && def.Properties != nil {
+		b.WriteString("\n")
+		writeMarkdownSchema(b, def)
+	} else if s.Properties != nil {
+		b.WriteString("\n")
+		writeMarkdownSchema(b, s)
+	}
+}
+
+// writeMarkdownSchema writes the fields of the object schema s as a table.
+func writeMarkdownSchema(b *bytes.Buffer, s *jsonschema.Schema) {
+	if s.Properties == nil || s.Properties.Len() == 0 {
+		fmt.Fprintf(b, "Type: %s\n", markdownType(s))
+		return
+	}
+	b.WriteString("| Field | Type | Required | Description |\n| --- | --- | --- | --- |\n")
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		desc := pair.Value.Description
+		if pair.Value.Deprecated {
+			desc = strings.TrimSpace("**Deprecated** " + desc)
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s |\n",
+			pair.Key, markdownType(pair.Value), markdownRequired(s, pair.Key), markdownCell(desc))
+	}
+}
+
+func markdownSchemaOf(typ any, request bool, renames map[string]string) *jsonschema.Schema {
+	s, _ := reflectTypeAs(typ, request)
+	if s.Ref != "" {
+		return &jsonschema.Schema{Ref: applyRenameToRef(s.Ref, renames)}
+	}
+	rewriteRefs(s, renames)
+	return s
+}
+
+func markdownRequired(s *jsonschema.Schema, name string) string {
+	if slices.Contains(s.Required, name) {
+		return "yes"
+	}
+	return ""
+}
+
+// markdownType returns a short description of the type of s, linking to the $defs it refers to.
+// Pipes are escaped, as the result is written in table cells.
+func markdownType(s *jsonschema.Schema) string {
+	if s == nil {
+		return "any"
+	}
+	if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok {
+		return fmt.Sprintf("[%s](#%s)", name, markdownAnchor(name))
+	}
+	if s.Const != nil {
+		return "`" + markdownLiteral(s.Const) + "`"
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, "`"+markdownLiteral(v)+"`")
+		}
+		return strings.Join(values, ` \| `)
+	}
+	if subs := append(slices.Clone(s.OneOf), s.AnyOf...); len(subs) > 0 {
+		types := make([]string, 0, len(subs))
+		for _, sub := range subs {
+			types = append(types, markdownType(sub))
+		}
+		return strings.Join(types, ` \| `)
+	}
+	if len(s.AllOf) > 0 && s.Type == "" {
+		types := make([]string, 0, len(s.AllOf))
+		for _, sub := range s.AllOf {
+			types = append(types, markdownType(sub))
+		}
+		return strings.Join(types, " & ")
+	}
+
+	var t string
+	switch s.Type {
+	case "":
+		t = "any"
+	case "array":
+		t = markdownType(s.Items) + "[]"
+	case "object":
+		t = "object"
+		if (s.Properties == nil || s.Properties.Len() == 0) && s.AdditionalProperties != nil && s.AdditionalProperties.Not == nil {
+			t = "map[string]" + markdownType(s.AdditionalProperties)
+		}
+	default:
+		t = s.Type
+	}
+	if s.Format != "" {
+		t += fmt.Sprintf(" (%s)", s.Format)
+	}
+	return t
+}
+
+// markdownAnchor returns the anchor GitHub generates for the heading text.
+func markdownAnchor(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r > 0x7f:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func markdownLiteral(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bs)
+}
+
+// markdownCell escapes text to be written in a table cell.
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", `\|`)
+	return strings.ReplaceAll(strings.TrimSpace(text), "\n", "<br>")
+}
+
+// writeMarkdownCurl writes example curl commands for every stage of the versions api is included in.
+// The request body is the value the mock server would synthesize from the request schema.
+func (e *endpoints) writeMarkdownCurl(b *bytes.Buffer, api API, defs jsonschema.Definitions, renames map[string]string) {
+	var options []string
+	switch api.AuthSchema.Type {
+	case "", authSchemaTypeNone:
+	case "Bearer":
+		options = append(options, fmt.Sprintf("-H '%s: Bearer <token>'", api.AuthSchema.Header))
+	default:
+		options = append(options, fmt.Sprintf("-H '%s: <token>'", api.AuthSchema.Header))
+	}
+	if api.hasRequestBody() {
+		body := markdownLiteral(mockValue(markdownSchemaOf(api.Request, true, renames), defs, 0))
+		options = append(options, "-H 'Content-Type: application/json'", "-d "+shellQuote(body))
+	}
+
+	var commands []string
+	for _, env := range e.env {
+		if len(api.Versions) > 0 && !api.Versions.Includes(env.Version) {
+			continue
+		}
+		for _, stage := range markdownStages() {
+			base := env.Domain.URL(stage)
+			if base == "" {
+				continue
+			}
+			url := strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(api.Path, "/")
+			command := fmt.Sprintf("# %s %s\ncurl", env.Version, stage)
+			if api.Method != http.MethodGet {
+				command += " -X " + api.Method
+			}
+			command += " " + shellQuote(url)
+			for _, option := range options {
+				command += " \\\n  " + option
+			}
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return
+	}
+	b.WriteString("\n#### Examples\n\n```sh\n")
+	b.WriteString(strings.Join(commands, "\n\n"))
+	b.WriteString("\n```\n")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,171 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// rawSchemaRefPrefix is the $ref prefix of the placeholders returned by schemaConverter
+// for OpenAPI 3.1, followed by the index of the raw schema.
+const rawSchemaRefPrefix = "#/x-endpoints-go/raw/"
+
+// openAPI31Document substitutes the raw JSON Schemas held by conv into the marshaled
+// OpenAPI document bs. The $defs are placed directly under components/schemas,
+// so no keyword is lost between the Go types and the spec.
+func openAPI31Document(bs []byte, conv *schemaConverter) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(bs, &doc); err != nil {
+		return nil, err
+	}
+
+	raw := make([]any, 0, len(conv.raw))
+	for _, s := range conv.raw {
+		v, err := rawSchemaValue(s)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, v)
+	}
+
+	schemas := map[string]any{}
+	for name, def := range conv.defs {
+		v, err := rawSchemaValue(def)
+		if err != nil {
+			return nil, err
+		}
+		schemas[name] = v
+	}
+
+	components, ok := doc["components"].(map[string]any)
+	if !ok {
+		components = map[string]any{}
+		doc["components"] = components
+	}
+	components["schemas"] = schemas
+
+	replaced, err := substituteRawSchemas(doc, raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(replaced)
+}
+
+// substituteRawSchemas replaces every placeholder {"$ref": rawSchemaRefPrefix + N} in v with raw[N].
+func substituteRawSchemas(v any, raw []any) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok && len(v) == 1 && strings.HasPrefix(ref, rawSchemaRefPrefix) {
+			i, err := strconv.Atoi(strings.TrimPrefix(ref, rawSchemaRefPrefix))
+			if err != nil || i < 0 || i >= len(raw) {
+				return nil, fmt.Errorf("invalid raw schema reference: %s", ref)
+			}
+			return raw[i], nil
+		}
+		for k, child := range v {
+			replaced, err := substituteRawSchemas(child, raw)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = replaced
+		}
+		return v, nil
+	case []any:
+		for i, child := range v {
+			replaced, err := substituteRawSchemas(child, raw)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = replaced
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// rawSchemaValue returns s as a generic JSON value suitable for an OpenAPI 3.1 document:
+// root-only keywords ($schema, $id, $defs) are dropped, $refs point at components/schemas and
+// nullable alternatives of a single type are expressed as type arrays.
+func rawSchemaValue(s *jsonschema.Schema) (any, error) {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(bs, &v); err != nil {
+		return nil, err
+	}
+	if m, ok := v.(map[string]any); ok {
+		delete(m, "$schema")
+		delete(m, "$id")
+		delete(m, "$defs")
+	}
+	return normalizeRawSchema(v), nil
+}
+
+func normalizeRawSchema(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if ref, ok := child.(string); ok && k == "$ref" {
+				v[k] = strings.Replace(ref, "#/$defs/", "#/components/schemas/", 1)
+				continue
+			}
+			v[k] = normalizeRawSchema(child)
+		}
+		collapseNullableOneOf(v)
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = normalizeRawSchema(child)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// collapseNullableOneOf rewrites {"oneOf": [{"type": T, ...}, {"type": "null"}]}
+// into {"type": [T, "null"], ...}. Alternatives with $ref are left as oneOf.
+func collapseNullableOneOf(m map[string]any) {
+	oneOf, ok := m["oneOf"].([]any)
+	if !ok || len(oneOf) != 2 {
+		return
+	}
+
+	var other map[string]any
+	nullable := false
+	for _, alt := range oneOf {
+		a, ok := alt.(map[string]any)
+		if !ok {
+			return
+		}
+		if t, ok := a["type"].(string); ok && t == "null" && len(a) == 1 {
+			nullable = true
+			continue
+		}
+		other = a
+	}
+	if !nullable || other == nil {
+		return
+	}
+	t, ok := other["type"].(string)
+	if !ok {
+		return
+	}
+	if _, hasRef := other["$ref"]; hasRef {
+		return
+	}
+
+	delete(m, "oneOf")
+	for k, v := range other {
+		if _, exists := m[k]; !exists {
+			m[k] = v
+		}
+	}
+	m["type"] = []any{t, "null"}
+}
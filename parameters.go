@@ -0,0 +1,244 @@
+package endpoints
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
+)
+
+type parameterLocation struct {
+	tag string
+	in  string
+}
+
+// parameterLocations maps the struct tags understood by Echo's DefaultBinder
+// to the corresponding OpenAPI parameter locations.
+func parameterLocations() []parameterLocation {
+	return []parameterLocation{
+		{tag: "param", in: openapi3.ParameterInPath},
+		{tag: "query", in: openapi3.ParameterInQuery},
+		{tag: "header", in: openapi3.ParameterInHeader},
+	}
+}
+
+// parameterField is a struct field bound from a path, query or header parameter.
+type parameterField struct {
+	field reflect.StructField
+	tag   string
+	in    string
+	name  string
+}
+
+// parameterFields returns the fields of t (including promoted fields of embedded structs)
+// that are bound from path, query or header parameters.
+func parameterFields(t reflect.Type) []parameterField {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []parameterField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && !hasParameterTag(f) {
+			fields = append(fields, parameterFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		for _, loc := range parameterLocations() {
+			name := strings.Split(f.Tag.Get(loc.tag), ",")[0]
+			if name == "" {
+				continue
+			}
+			fields = append(fields, parameterField{field: f, tag: loc.tag, in: loc.in, name: name})
+			break
+		}
+	}
+	return fields
+}
+
+func hasParameterTag(f reflect.StructField) bool {
+	for _, loc := range parameterLocations() {
+		if f.Tag.Get(loc.tag) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonFieldName returns the JSON property name of f, or "" if f is not serialized.
+func jsonFieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	default:
+		return name
+	}
+}
+
+// stripParameterFields removes the properties bound from path, query or header parameters
+// from the schema reflected from t.
+func stripParameterFields(s *jsonschema.Schema, t reflect.Type) {
+	if s == nil || s.Properties == nil {
+		return
+	}
+	for _, pf := range parameterFields(t) {
+		name := jsonFieldName(pf.field)
+		if name == "" {
+			continue
+		}
+		s.Properties.Delete(name)
+		required := s.Required[:0]
+		for _, r := range s.Required {
+			if r != name {
+				required = append(required, r)
+			}
+		}
+		s.Required = required
+	}
+	if len(s.Required) == 0 {
+		s.Required = nil
+	}
+}
+
+// hasBodyFields reports whether typ has any field that is bound from the JSON body.
+// Non-struct types are always treated as a body.
+func hasBodyFields(typ any) bool {
+	t := reflect.TypeOf(typ)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return true
+	}
+	return hasBodyFieldsOfType(t)
+}
+
+func hasBodyFieldsOfType(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && !hasParameterTag(f) {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && hasBodyFieldsOfType(ft) {
+				return true
+			}
+			continue
+		}
+		if f.IsExported() && !hasParameterTag(f) && jsonFieldName(f) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// reflectParameters reflects the fields of typ tagged with tag, naming the properties by that tag.
+// Returns the object schema holding the parameter properties.
+func reflectParameters(typ any, tag string, renames map[string]string) *jsonschema.Schema {
+	r := newReflector(map[string]string{}, map[string]reflect.Type{})
+	r.FieldNameTag = tag
+	r.RequiredFromJSONSchemaTags = true
+
+	schema := r.Reflect(typ)
+	if schema.Ref != "" {
+		schema = schema.Definitions[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+	}
+	applyValidateTags(schema, reflect.TypeOf(typ), tag)
+	rewriteRefs(schema, renames)
+	return schema
+}
+
+// parameterSchema returns an object schema holding the parameters of typ bound from in,
+// or nil if typ has no such parameters.
+// Path parameters are always required; query and header parameters are required only when
+// marked so in their jsonschema tag.
+func parameterSchema(typ any, in string, renames map[string]string) *jsonschema.Schema {
+	var fields []parameterField
+	for _, pf := range parameterFields(reflect.TypeOf(typ)) {
+		if pf.in == in {
+			fields = append(fields, pf)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	reflected := reflectParameters(typ, fields[0].tag, renames)
+	if reflected == nil || reflected.Properties == nil {
+		return nil
+	}
+
+	schema := &jsonschema.Schema{
+		Type:       "object",
+		Properties: jsonschema.NewProperties(),
+	}
+	for _, pf := range fields {
+		property, ok := reflected.Properties.Get(pf.name)
+		if !ok {
+			continue
+		}
+		schema.Properties.Set(pf.name, property)
+		if in == openapi3.ParameterInPath || slices.Contains(reflected.Required, pf.name) {
+			schema.Required = append(schema.Required, pf.name)
+		}
+	}
+	return schema
+}
+
+// requestParameters derives typed OpenAPI parameters from the param, query and header tags of typ.
+func requestParameters(typ any, conv *schemaConverter, renames map[string]string, description string) openapi3.Parameters {
+	parameters := openapi3.Parameters{}
+	for _, loc := range parameterLocations() {
+		schema := parameterSchema(typ, loc.in, renames)
+		if schema == nil {
+			continue
+		}
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			desc := pair.Value.Description
+			if desc == "" {
+				desc = description
+			}
+			parameters = append(parameters, &openapi3.ParameterRef{
+				Value: &openapi3.Parameter{
+					Name:        pair.Key,
+					In:          loc.in,
+					Description: desc,
+					Required:    slices.Contains(schema.Required, pair.Key),
+					Schema:      conv.schemaRef(pair.Value),
+				},
+			})
+		}
+	}
+	return parameters
+}
+
+// mergeParameters merges typed parameters into parameters, replacing any parameter
+// with the same name and location.
+func mergeParameters(parameters openapi3.Parameters, typed openapi3.Parameters) openapi3.Parameters {
+	merged := openapi3.Parameters{}
+	for _, p := range parameters {
+		if t := typed.GetByInAndName(p.Value.In, p.Value.Name); t != nil {
+			merged = append(merged, &openapi3.ParameterRef{Value: t})
+			continue
+		}
+		merged = append(merged, p)
+	}
+	for _, t := range typed {
+		if merged.GetByInAndName(t.Value.In, t.Value.Name) == nil {
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,40 @@
+package endpoints
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProblemHTTPErrorHandler は、handlerが返したエラーを RFC 7807 の application/problem+json として返す echo.HTTPErrorHandler
+// e.HTTPErrorHandler = endpoints.ProblemHTTPErrorHandler のように設定して使う
+func ProblemHTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+	if err := writeProblem(c, problemFromError(err)); err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// echoProblem は、echo.HTTPErrorをProblemに変換する
+func echoProblem(err error) (*Problem, bool) {
+	var he *echo.HTTPError
+	if !errors.As(err, &he) {
+		return nil, false
+	}
+	return newProblem(he.Code, httpErrorDetail(he.Message)), true
+}
+
+// httpErrorDetail returns the message of an echo.HTTPError as the problem detail.
+// Other values such as maps or structs are not meant for the client, so they are dropped.
+func httpErrorDetail(message any) string {
+	switch m := message.(type) {
+	case string:
+		return m
+	case error:
+		return m.Error()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,48 @@
+package endpoints
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v5"
+)
+
+// このファイルには、Echoのバージョンによって実装が異なる処理をまとめている
+
+// swapResponseWriter は、cのレスポンスの書き込み先をwに差し替え、元の書き込み先と、元に戻す関数を返す
+func swapResponseWriter(c *echo.Context, w http.ResponseWriter) (original http.ResponseWriter, restore func()) {
+	res, err := echo.UnwrapResponse(c.Response())
+	if err != nil {
+		return c.Response(), func() {}
+	}
+	original = res.ResponseWriter
+	res.ResponseWriter = w
+	return original, func() {
+		res.ResponseWriter = original
+	}
+}
+
+// resetResponse は、書き込み済みとして扱われているcのレスポンスを未送信の状態に戻す
+func resetResponse(c *echo.Context) {
+	res, err := echo.UnwrapResponse(c.Response())
+	if err != nil {
+		return
+	}
+	res.Committed = false
+	res.Status = 0
+	res.Size = 0
+}
+
+// logResponseMismatch は、レスポンスが公開しているスキーマと一致しないことをログに出力する
+func logResponseMismatch(c *echo.Context, api API, err error) {
+	c.Logger().Error("endpoints: response does not match the published schema", "api", api.Name, "error", err)
+}
+
+// bindQueryParams は、cのクエリパラメータをqueryタグのフィールドにBindする
+func bindQueryParams(c *echo.Context, r any) error {
+	return echo.BindQueryParams(c, r)
+}
+
+// bindHeaders は、cのヘッダをheaderタグのフィールドにBindする
+func bindHeaders(c *echo.Context, r any) error {
+	return echo.BindHeaders(c, r)
+}
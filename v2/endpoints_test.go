@@ -2,16 +2,30 @@ package endpoints
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
 	"github.com/labstack/echo/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/matsuri-tech/endpoints-go/v2/testfixture/collision_a"
 	"github.com/matsuri-tech/endpoints-go/v2/testfixture/collision_b"
+	"github.com/matsuri-tech/endpoints-go/v2/testfixture/goclient"
 )
 
 type SampleModel struct {
@@ -209,12 +223,6 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
         ],
         "type": "object"
       }
-    },
-    "securitySchemes": {
-      "auth": {
-        "in": "header",
-        "type": "apiKey"
-      }
     }
   },
   "info": {
@@ -259,11 +267,7 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
             "description": "Generated by endpoints-go"
           }
         },
-        "security": [
-          {
-            "auth": []
-          }
-        ],
+        "security": [],
         "summary": "getSamplesWithQuery"
       },
       "post": {
@@ -302,11 +306,7 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
             "description": "Generated by endpoints-go"
           }
         },
-        "security": [
-          {
-            "auth": []
-          }
-        ],
+        "security": [],
         "summary": "createSample"
       },
       "patch": {
@@ -334,15 +334,11 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
           "description": "Generated by endpoints-go"
         },
         "responses": {
-          "200": {
+          "204": {
             "description": "Generated by endpoints-go"
           }
         },
-        "security": [
-          {
-            "auth": []
-          }
-        ],
+        "security": [],
         "summary": "patchSample"
       }
     },
@@ -382,11 +378,7 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
             "description": "Generated by endpoints-go"
           }
         },
-        "security": [
-          {
-            "auth": []
-          }
-        ],
+        "security": [],
         "summary": "getSamplesWithQueryAnother"
       }
     },
@@ -406,11 +398,7 @@ func TestEchoWrapper_GenerateOpenApi(t *testing.T) {
             "description": "Generated by endpoints-go"
           }
         },
-        "security": [
-          {
-            "auth": []
-          }
-        ],
+        "security": [],
         "summary": "getAllSamples"
       }
     }
@@ -579,7 +567,8 @@ func TestEchoWrapper_Generate(t *testing.T) {
         "request": {
  		  "$ref": "#/$defs/SampleModel"
  		},
-        "response": null
+        "response": null,
+        "status": 204
       }
     }
   },
@@ -779,3 +768,1989 @@ func TestMergeDefs_AdditionalProperties(t *testing.T) {
 	require.True(t, ok)
 	assert.Equal(t, "#/$defs/"+collisionAQualName, additionalProps["$ref"])
 }
+
+// TestGroupWrapper_NestedGroup verifies that nested groups compose the prefix and
+// inherit versions and frontends from their parent group.
+func TestGroupWrapper_NestedGroup(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(
+		Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}},
+		Env{Version: "v2", Domain: Domain{Local: "http://localhost:8000"}},
+	)
+	ew.AddFrontends("guest", "manager")
+
+	admin := ew.GroupWithVersionsAndFrontends("/admin", []string{"v2"}, nil)
+	users := admin.GroupWithVersionsAndFrontends("/users", nil, []string{"manager"})
+	notes := users.SubGroup("/:id/notes")
+	GwGET(notes, "", func(c *echo.Context) (SampleModel, error) {
+		return SampleModel{}, nil
+	}, Desc{
+		Name: "getNotes",
+		Desc: "get notes",
+	})
+
+	require.Len(t, ew.endpoints.api, 1)
+	api := ew.endpoints.api[0]
+	assert.Equal(t, "/admin/users/:id/notes", api.Path)
+	assert.Equal(t, Versions{"v2"}, api.Versions)
+	assert.Equal(t, Frontends{"manager"}, api.Frontends)
+
+	assert.Empty(t, ew.endpoints.generateAPIList("v1", nil).Keys())
+	assert.Equal(t, []string{"getNotes"}, ew.endpoints.generateAPIListByFrontend("v2", "manager", nil).Keys())
+	assert.Empty(t, ew.endpoints.generateAPIListByFrontend("v2", "guest", nil).Keys())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/users/1/notes", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestGroupWrapper_GroupOptions verifies that GroupOptions are applied as defaults to
+// every Desc registered through the group and can be overridden per endpoint.
+func TestGroupWrapper_GroupOptions(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+
+	admin := ew.GroupWithOptions("/admin", GroupOptions{
+		AuthSchema: NewBearerAuthSchema(),
+		Tags:       []string{"admin"},
+		Metadata:   map[string]string{"owner": "platform", "tier": "internal"},
+	})
+	legacy := admin.GroupWithOptions("/legacy", GroupOptions{
+		Tags:       []string{"legacy"},
+		Deprecated: true,
+	})
+	handler := func(c *echo.Context) (SampleModel, error) {
+		return SampleModel{}, nil
+	}
+	GwGET(admin, "/users", handler, Desc{Name: "adminUsers"})
+	GwGET(admin, "/keys", handler, Desc{
+		Name:       "adminKeys",
+		AuthSchema: NewApiKeyAuthSchema(),
+		Metadata:   map[string]string{"tier": "restricted"},
+	})
+	GwGET(legacy, "/reports", handler, Desc{Name: "legacyReports"})
+	GwGET(admin, "/debug", handler, Desc{Name: "adminDebug", Hidden: true})
+
+	require.Len(t, ew.endpoints.api, 4)
+	users, keys, reports := ew.endpoints.api[0], ew.endpoints.api[1], ew.endpoints.api[2]
+
+	assert.Equal(t, NewBearerAuthSchema(), users.AuthSchema)
+	assert.Equal(t, []string{"admin"}, users.Tags)
+	assert.False(t, users.Deprecated)
+
+	assert.Equal(t, NewApiKeyAuthSchema(), keys.AuthSchema)
+	assert.Equal(t, map[string]string{"owner": "platform", "tier": "restricted"}, keys.Metadata)
+
+	assert.Equal(t, "/admin/legacy/reports", reports.Path)
+	assert.Equal(t, NewBearerAuthSchema(), reports.AuthSchema)
+	assert.Equal(t, []string{"admin", "legacy"}, reports.Tags)
+	assert.True(t, reports.Deprecated)
+
+	assert.Equal(t, []string{"adminUsers", "adminKeys", "legacyReports"}, ew.endpoints.generateAPIList("v1", nil).Keys())
+
+	schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, schema.Paths.Value("/admin/debug"))
+	assert.True(t, schema.Paths.Value("/admin/legacy/reports").Get.Deprecated)
+	assert.Equal(t, []string{"admin", "legacy"}, schema.Paths.Value("/admin/legacy/reports").Get.Tags)
+	assert.NotNil(t, schema.Tags.Get("legacy"))
+}
+
+// TestEchoWrapper_AddAPI_RootLevel verifies that root-level routes map every Desc field
+// the same way as routes registered through a GroupWrapper.
+func TestEchoWrapper_AddAPI_RootLevel(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(
+		Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}},
+		Env{Version: "v2", Domain: Domain{Local: "http://localhost:8000"}},
+	)
+	ew.AddFrontends("guest", "manager")
+
+	sampleHandler := NewSampleHandler()
+	ew.GET("/users", sampleHandler.GetWithQuery, Desc{
+		Name:       "userIndex",
+		AuthSchema: NewBearerAuthSchema(),
+	})
+	ew.GET("/messages", sampleHandler.GetWithQuery, Desc{
+		Name:     "messageIndex",
+		Versions: []string{"v2"},
+	})
+	EwGET(ew, "/inquiries", sampleHandler.GetWithQueryWrapper, Desc{
+		Name:      "inquiryIndex",
+		Frontends: []string{"manager"},
+	})
+	ew.POSTTyped("/favorites", sampleHandler.GetWithQuery, Desc{
+		Name:       "createFavorite",
+		AuthSchema: NewApiKeyAuthSchema(),
+		Versions:   []string{"v2"},
+		Frontends:  []string{"guest"},
+	}, CreateSampleInput{}, CreateSampleOutput{})
+
+	tests := []struct {
+		version  string
+		frontend string
+		expected []string
+	}{
+		{"v1", "", []string{"userIndex", "inquiryIndex"}},
+		{"v2", "", []string{"userIndex", "messageIndex", "inquiryIndex", "createFavorite"}},
+		{"v1", "guest", []string{"userIndex"}},
+		{"v1", "manager", []string{"userIndex", "inquiryIndex"}},
+		{"v2", "guest", []string{"userIndex", "messageIndex", "createFavorite"}},
+		{"v2", "manager", []string{"userIndex", "messageIndex", "inquiryIndex"}},
+	}
+	for _, tt := range tests {
+		if tt.frontend == "" {
+			assert.Equal(t, tt.expected, ew.endpoints.generateAPIList(tt.version, nil).Keys(), tt.version)
+		} else {
+			assert.Equal(t, tt.expected, ew.endpoints.generateAPIListByFrontend(tt.version, tt.frontend, nil).Keys(), tt.frontend+"-"+tt.version)
+		}
+	}
+
+	authSchemas := map[string]AuthSchema{}
+	for _, api := range ew.endpoints.api {
+		authSchemas[api.Name] = api.generatedApi(nil).AuthSchema
+	}
+	assert.Equal(t, NewBearerAuthSchema(), authSchemas["userIndex"])
+	assert.Equal(t, AuthSchema{}, authSchemas["messageIndex"])
+	assert.Equal(t, NewApiKeyAuthSchema(), authSchemas["createFavorite"])
+}
+
+type SearchSamplesInput struct {
+	ID     int      `param:"id"`
+	Page   int      `query:"page" jsonschema:"required"`
+	Sort   string   `query:"sort" jsonschema:"enum=asc,enum=desc"`
+	Tags   []string `query:"tags"`
+	Locale string   `header:"Accept-Language"`
+	Name   string   `json:"name"`
+}
+
+type ListSamplesInput struct {
+	Page  int    `query:"page"`
+	Order string `query:"order"`
+}
+
+// SearchFilter is nested in the body of NestedSearchInput, so its query tag is not bound.
+type SearchFilter struct {
+	Page int    `query:"page" json:"page"`
+	Name string `json:"name"`
+}
+
+type NestedSearchInput struct {
+	ID     int          `param:"id"`
+	Filter SearchFilter `json:"filter"`
+}
+
+// TestGenerateOpenApi_TypedParameters verifies that path, query and header parameters are
+// derived from the param, query and header tags of the request type and excluded from the body.
+func TestGenerateOpenApi_TypedParameters(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples/:id/search", sampleHandler.GetWithQuery, Desc{
+		Name:  "searchSamples",
+		Query: "sort=asc",
+	}, SearchSamplesInput{}, GetAllSamplesOutput{})
+	ew.DELETETyped("/samples", sampleHandler.GetWithQuery, Desc{
+		Name: "deleteSamples",
+	}, ListSamplesInput{}, nil)
+
+	schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+
+	op := schema.Paths.Value("/samples/{id}/search").Post
+	require.NotNil(t, op)
+	require.Len(t, op.Parameters, 5)
+
+	sort := op.Parameters.GetByInAndName("query", "sort")
+	require.NotNil(t, sort)
+	assert.False(t, sort.Required)
+	assert.Equal(t, []any{"asc", "desc"}, sort.Schema.Value.Enum)
+	assert.Equal(t, "query", op.Parameters[0].Value.In, "typed parameter should replace the one derived from Desc.Query in place")
+
+	id := op.Parameters.GetByInAndName("path", "id")
+	require.NotNil(t, id)
+	assert.True(t, id.Required)
+	assert.True(t, id.Schema.Value.Type.Is("integer"))
+
+	page := op.Parameters.GetByInAndName("query", "page")
+	require.NotNil(t, page)
+	assert.True(t, page.Required)
+	assert.True(t, page.Schema.Value.Type.Is("integer"))
+
+	tags := op.Parameters.GetByInAndName("query", "tags")
+	require.NotNil(t, tags)
+	assert.False(t, tags.Required)
+	assert.True(t, tags.Schema.Value.Type.Is("array"))
+	assert.True(t, tags.Schema.Value.Items.Value.Type.Is("string"))
+
+	locale := op.Parameters.GetByInAndName("header", "Accept-Language")
+	require.NotNil(t, locale)
+	assert.True(t, locale.Schema.Value.Type.Is("string"))
+
+	body := schema.Components.Schemas["SearchSamplesInput"].Value
+	assert.Equal(t, []string{"name"}, body.Required)
+	assert.Len(t, body.Properties, 1)
+	assert.Contains(t, body.Properties, "name")
+
+	deleteOp := schema.Paths.Value("/samples").Delete
+	require.NotNil(t, deleteOp)
+	assert.Len(t, deleteOp.Parameters, 2)
+	assert.Nil(t, deleteOp.RequestBody)
+
+	// パラメータのタグは、リクエストの型自体のフィールドにのみ適用される
+	ew.POSTTyped("/samples/:id/filter", sampleHandler.GetWithQuery, Desc{
+		Name: "filterSamples",
+	}, NestedSearchInput{}, GetAllSamplesOutput{})
+	schema, err = ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+	nested := schema.Components.Schemas["NestedSearchInput"].Value
+	assert.Len(t, nested.Properties, 1)
+	assert.Contains(t, nested.Properties, "filter")
+	filter := schema.Components.Schemas["SearchFilter"].Value
+	assert.Len(t, filter.Properties, 2)
+	assert.Contains(t, filter.Properties, "page")
+	assert.Nil(t, schema.Paths.Value("/samples/{id}/filter").Post.Parameters.GetByInAndName("query", "page"))
+}
+
+// boundSampleOutput echoes the parameters bound into SearchSamplesInput.
+// Its parameter-tagged fields are serialized like any other field.
+type boundSampleOutput struct {
+	ID     int    `param:"id" json:"id"`
+	Page   int    `query:"page" json:"page"`
+	Locale string `header:"Accept-Language" json:"locale"`
+	Name   string `json:"name"`
+}
+
+// TestTypedHandler_BindsParameters verifies that query parameters of non-GET requests and headers
+// are bound as documented, and that response types keep their parameter-tagged fields.
+func TestTypedHandler_BindsParameters(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	EwPOST(ew, "/samples/:id/search", func(c *echo.Context, req SearchSamplesInput) (boundSampleOutput, error) {
+		return boundSampleOutput{ID: req.ID, Page: req.Page, Locale: req.Locale, Name: req.Name}, nil
+	}, Desc{Name: "searchSamples"})
+
+	req := httptest.NewRequest(http.MethodPost, "/samples/3/search?page=2", strings.NewReader(`{"name": "foo"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Accept-Language", "ja")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id": 3, "page": 2, "locale": "ja", "name": "foo"}`, rec.Body.String())
+
+	schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+	assert.Len(t, schema.Components.Schemas["SearchSamplesInput"].Value.Properties, 1)
+	response := schema.Components.Schemas["boundSampleOutput"].Value
+	assert.Len(t, response.Properties, 4)
+	assert.Contains(t, response.Properties, "page")
+}
+
+type noopValidator struct{}
+
+func (noopValidator) Validate(i any) error {
+	return nil
+}
+
+// TestEwGETWithRequest verifies that GET handlers with a request struct bind path and query
+// parameters at runtime and document the request as parameters rather than a body.
+func TestEwGETWithRequest(t *testing.T) {
+	e := echo.New()
+	e.Validator = noopValidator{}
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+
+	type listNotesInput struct {
+		UserID int    `param:"userId"`
+		Page   int    `query:"page" jsonschema:"required"`
+		Order  string `query:"order" jsonschema:"enum=asc,enum=desc"`
+	}
+	users := ew.Group("/users")
+	GwGETWithRequest(users, "/:userId/notes", func(c *echo.Context, req listNotesInput) (GetAllSamplesOutput, error) {
+		return GetAllSamplesOutput{Total: req.UserID*100 + req.Page}, nil
+	}, Desc{Name: "listNotes"})
+	EwGETWithRequest(ew, "/samples", func(c *echo.Context, req ListSamplesInput) (GetAllSamplesOutput, error) {
+		return GetAllSamplesOutput{Total: req.Page}, nil
+	}, Desc{Name: "listSamples"})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/3/notes?page=2&order=asc", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"samples": null, "total": 302}`, rec.Body.String())
+
+	actual, err := ew.endpoints.generateJson()
+	require.NoError(t, err)
+	var result map[string]struct {
+		API map[string]map[string]any `json:"api"`
+	}
+	require.NoError(t, json.Unmarshal(actual, &result))
+
+	listNotes := result["v1"].API["listNotes"]
+	assert.Nil(t, listNotes["request"])
+	assert.Equal(t, map[string]any{
+		"path": map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"userId": map[string]any{"type": "integer"}},
+			"required":   []any{"userId"},
+		},
+		"query": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"page":  map[string]any{"type": "integer"},
+				"order": map[string]any{"type": "string", "enum": []any{"asc", "desc"}},
+			},
+			"required": []any{"page"},
+		},
+	}, listNotes["params"])
+
+	var defs map[string]any
+	require.NoError(t, json.Unmarshal(actual, &struct {
+		Defs *map[string]any `json:"$defs"`
+	}{&defs}))
+	assert.NotContains(t, defs, "ListSamplesInput")
+
+	schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+	op := schema.Paths.Value("/samples").Get
+	require.NotNil(t, op)
+	assert.Nil(t, op.RequestBody)
+	assert.NotNil(t, op.Parameters.GetByInAndName("query", "page"))
+	assert.NotNil(t, op.Parameters.GetByInAndName("query", "order"))
+	assert.NotContains(t, schema.Components.Schemas, "ListSamplesInput")
+}
+
+type sampleKind string
+
+func (sampleKind) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "string", Const: "sample"}
+}
+
+type keywordSample struct {
+	Label     string       `json:"label" jsonschema:"title=Label,description=A label"`
+	Status    string       `json:"status" jsonschema:"enum=active,enum=inactive,default=active"`
+	Kind      sampleKind   `json:"kind"`
+	CreatedAt time.Time    `json:"created_at"`
+	Email     string       `json:"email" jsonschema:"format=email,example=foo@example.com,pattern=^.+@.+$,minLength=3,maxLength=254"`
+	Age       int          `json:"age" jsonschema:"minimum=0,maximum=150,multipleOf=1"`
+	Ratio     float64      `json:"ratio" jsonschema:"exclusiveMinimum=0,exclusiveMaximum=1"`
+	Tags      []string     `json:"tags" jsonschema:"minItems=1,maxItems=5,uniqueItems=true"`
+	Nickname  *string      `json:"nickname" jsonschema:"nullable"`
+	Model     *SampleModel `json:"model" jsonschema:"nullable"`
+	ID        any          `json:"id" jsonschema:"oneof_type=string;integer"`
+	Amount    any          `json:"amount" jsonschema:"anyof_type=string;number"`
+	Token     string       `json:"token" jsonschema:"readOnly=true"`
+}
+
+func (keywordSample) JSONSchemaExtend(s *jsonschema.Schema) {
+	s.AllOf = []*jsonschema.Schema{{MinProperties: ptrUint64(1), MaxProperties: ptrUint64(20)}}
+	s.Not = &jsonschema.Schema{Required: []string{"unknown"}}
+}
+
+func ptrUint64(v uint64) *uint64 {
+	return &v
+}
+
+// TestConvertJSONSchemaDefToOpenAPI_Keywords verifies that every keyword produced by the
+// jsonschema reflector that OpenAPI 3.0 can represent survives the conversion.
+func TestConvertJSONSchemaDefToOpenAPI_Keywords(t *testing.T) {
+	reflected, shortNames := reflectType(keywordSample{})
+	defs, _ := mergeDefs([]reflectResult{{schema: reflected, shortNames: shortNames}})
+	require.Contains(t, defs, "keywordSample")
+
+	schema := convertJSONSchemaDefToOpenAPI(defs["keywordSample"], defs)
+	prop := func(name string) *openapi3.Schema {
+		ref, ok := schema.Properties[name]
+		require.True(t, ok, name)
+		require.NotNil(t, ref.Value, name)
+		return ref.Value
+	}
+
+	t.Run("title and description", func(t *testing.T) {
+		assert.Equal(t, "Label", prop("label").Title)
+		assert.Equal(t, "A label", prop("label").Description)
+	})
+	t.Run("enum and default", func(t *testing.T) {
+		assert.Equal(t, []any{"active", "inactive"}, prop("status").Enum)
+		assert.Equal(t, "active", prop("status").Default)
+	})
+	t.Run("const", func(t *testing.T) {
+		kind := convertJSONSchemaDefToOpenAPI(defs["sampleKind"], defs)
+		assert.Equal(t, []any{"sample"}, kind.Enum)
+	})
+	t.Run("format", func(t *testing.T) {
+		assert.Equal(t, "date-time", prop("created_at").Format)
+		assert.Equal(t, "email", prop("email").Format)
+	})
+	t.Run("examples", func(t *testing.T) {
+		assert.Equal(t, "foo@example.com", prop("email").Example)
+	})
+	t.Run("pattern and length", func(t *testing.T) {
+		assert.Equal(t, "^.+@.+$", prop("email").Pattern)
+		assert.Equal(t, uint64(3), prop("email").MinLength)
+		assert.Equal(t, ptrUint64(254), prop("email").MaxLength)
+	})
+	t.Run("minimum, maximum and multipleOf", func(t *testing.T) {
+		require.NotNil(t, prop("age").Min)
+		require.NotNil(t, prop("age").Max)
+		require.NotNil(t, prop("age").MultipleOf)
+		assert.InDelta(t, 0, *prop("age").Min, 0)
+		assert.InDelta(t, 150, *prop("age").Max, 0)
+		assert.InDelta(t, 1, *prop("age").MultipleOf, 0)
+		assert.False(t, prop("age").ExclusiveMin)
+	})
+	t.Run("exclusive minimum and maximum", func(t *testing.T) {
+		require.NotNil(t, prop("ratio").Min)
+		require.NotNil(t, prop("ratio").Max)
+		assert.InDelta(t, 0, *prop("ratio").Min, 0)
+		assert.InDelta(t, 1, *prop("ratio").Max, 0)
+		assert.True(t, prop("ratio").ExclusiveMin)
+		assert.True(t, prop("ratio").ExclusiveMax)
+	})
+	t.Run("array keywords", func(t *testing.T) {
+		assert.Equal(t, uint64(1), prop("tags").MinItems)
+		assert.Equal(t, ptrUint64(5), prop("tags").MaxItems)
+		assert.True(t, prop("tags").UniqueItems)
+	})
+	t.Run("nullable", func(t *testing.T) {
+		assert.True(t, prop("nickname").Nullable)
+		assert.True(t, prop("nickname").Type.Is("string"))
+		assert.Empty(t, prop("nickname").OneOf)
+
+		assert.True(t, prop("model").Nullable)
+		require.Len(t, prop("model").AllOf, 1)
+		assert.Equal(t, "#/components/schemas/SampleModel", prop("model").AllOf[0].Ref)
+	})
+	t.Run("oneOf and anyOf", func(t *testing.T) {
+		require.Len(t, prop("id").OneOf, 2)
+		assert.True(t, prop("id").OneOf[0].Value.Type.Is("string"))
+		assert.True(t, prop("id").OneOf[1].Value.Type.Is("integer"))
+		require.Len(t, prop("amount").AnyOf, 2)
+		assert.True(t, prop("amount").AnyOf[1].Value.Type.Is("number"))
+	})
+	t.Run("readOnly", func(t *testing.T) {
+		assert.True(t, prop("token").ReadOnly)
+	})
+	t.Run("allOf, not and object keywords", func(t *testing.T) {
+		require.Len(t, schema.AllOf, 1)
+		assert.Equal(t, uint64(1), schema.AllOf[0].Value.MinProps)
+		assert.Equal(t, ptrUint64(20), schema.AllOf[0].Value.MaxProps)
+		require.NotNil(t, schema.Not)
+		assert.Equal(t, []string{"unknown"}, schema.Not.Value.Required)
+	})
+	t.Run("valid OpenAPI 3.0", func(t *testing.T) {
+		doc := openapi3.T{
+			OpenAPI:    "3.0.0",
+			Info:       &openapi3.Info{Title: "test", Version: "1"},
+			Paths:      openapi3.NewPaths(),
+			Components: &openapi3.Components{Schemas: openapi3.Schemas{"keywordSample": {Value: schema}}},
+		}
+		for _, name := range []string{"SampleModel", "sampleKind"} {
+			doc.Components.Schemas[name] = &openapi3.SchemaRef{Value: convertJSONSchemaDefToOpenAPI(defs[name], defs)}
+		}
+		require.NoError(t, openapi3.NewLoader().ResolveRefsIn(&doc, nil))
+		assert.NoError(t, doc.Validate(context.Background()))
+	})
+}
+
+// TestGenerateOpenApi_OpenApi31 verifies that OpenAPI 3.1 output places the JSON Schema
+// $defs directly under components/schemas without the lossy 3.0 conversion.
+func TestGenerateOpenApi_OpenApi31(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples/:id/search", sampleHandler.GetWithQuery, Desc{
+		Name: "searchSamples",
+	}, SearchSamplesInput{}, keywordSample{})
+	ew.GETTyped("/samples", sampleHandler.GetWithQuery, Desc{
+		Name: "listSamples",
+	}, []SampleModel{})
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, ew.endpoints.generateOpenApiJson(buf, OpenApiGeneratorConfig{OpenApiVersion: OpenApiVersion31}))
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+		Paths map[string]map[string]struct {
+			Parameters []map[string]any `json:"parameters"`
+			Responses  map[string]struct {
+				Content map[string]struct {
+					Schema map[string]any `json:"schema"`
+				} `json:"content"`
+			} `json:"responses"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.NotContains(t, buf.String(), rawSchemaRefPrefix)
+	assert.NotContains(t, buf.String(), "#/$defs/")
+
+	sample := doc.Components.Schemas["keywordSample"]
+	require.NotNil(t, sample)
+	props, ok := sample["properties"].(map[string]any)
+	require.True(t, ok)
+
+	assert.Equal(t, map[string]any{"type": []any{"string", "null"}}, props["nickname"])
+	assert.Equal(t, map[string]any{"oneOf": []any{
+		map[string]any{"$ref": "#/components/schemas/SampleModel"},
+		map[string]any{"type": "null"},
+	}}, props["model"])
+	assert.Equal(t, map[string]any{"$ref": "#/components/schemas/sampleKind"}, props["kind"])
+	assert.Equal(t, map[string]any{"type": "string", "const": "sample"}, doc.Components.Schemas["sampleKind"])
+	assert.Equal(t, map[string]any{"type": "number", "exclusiveMinimum": float64(0), "exclusiveMaximum": float64(1)}, props["ratio"])
+	email, ok := props["email"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, []any{"foo@example.com"}, email["examples"])
+
+	params := doc.Paths["/samples/{id}/search"]["post"].Parameters
+	require.NotEmpty(t, params)
+	var sort map[string]any
+	for _, p := range params {
+		if p["name"] == "sort" {
+			sort = p
+		}
+	}
+	require.NotNil(t, sort)
+	assert.Equal(t, map[string]any{"type": "string", "enum": []any{"asc", "desc"}}, sort["schema"])
+
+	list := doc.Paths["/samples"]["get"].Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, map[string]any{
+		"type":  "array",
+		"items": map[string]any{"$ref": "#/components/schemas/SampleModel"},
+	}, list)
+}
+
+func TestGenerateOpenApi_PerVersion(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(
+		Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000", Prod: "https://hoge.com"}},
+		Env{Version: "v2", Domain: Domain{Local: "http://localhost:8000", Prod: "https://v2.hoge.com"}},
+	)
+	ew.AddFrontends("guest", "manager")
+	sampleHandler := NewSampleHandler()
+	ew.GETTyped("/samples", sampleHandler.GetWithQuery, Desc{
+		Name: "listSamples",
+	}, []SampleModel{})
+	ew.POSTTyped("/keywords", sampleHandler.GetWithQuery, Desc{
+		Name:      "createKeyword",
+		Versions:  []string{"v2"},
+		Frontends: []string{"guest"},
+	}, keywordSample{}, keywordSample{})
+
+	generate := func(config OpenApiGeneratorConfig) openapi3.T {
+		t.Helper()
+		schema, err := ew.endpoints.generateOpenApiSchema(config)
+		require.NoError(t, err)
+		return schema
+	}
+
+	t.Run("version only", func(t *testing.T) {
+		schema := generate(OpenApiGeneratorConfig{Version: "v1"})
+		assert.Equal(t, "v1", schema.Info.Version)
+		assert.NotNil(t, schema.Paths.Find("/samples"))
+		assert.Nil(t, schema.Paths.Find("/keywords"))
+		assert.Contains(t, schema.Components.Schemas, "SampleModel")
+		assert.NotContains(t, schema.Components.Schemas, "keywordSample")
+		assert.NotContains(t, schema.Components.Schemas, "sampleKind")
+
+		var descriptions []string
+		for _, s := range schema.Servers {
+			descriptions = append(descriptions, s.Description)
+		}
+		assert.Equal(t, []string{"v1 at local", "v1 at dev", "v1 at prod"}, descriptions)
+	})
+
+	t.Run("frontend and version", func(t *testing.T) {
+		guest := generate(OpenApiGeneratorConfig{Version: "v2", Frontend: "guest"})
+		assert.NotNil(t, guest.Paths.Find("/keywords"))
+		assert.Contains(t, guest.Components.Schemas, "keywordSample")
+		assert.Contains(t, guest.Components.Schemas, "sampleKind")
+
+		manager := generate(OpenApiGeneratorConfig{Version: "v2", Frontend: "manager"})
+		assert.Nil(t, manager.Paths.Find("/keywords"))
+		assert.NotContains(t, manager.Components.Schemas, "keywordSample")
+	})
+
+	t.Run("no filter", func(t *testing.T) {
+		schema := generate(OpenApiGeneratorConfig{})
+		assert.NotNil(t, schema.Paths.Find("/samples"))
+		assert.NotNil(t, schema.Paths.Find("/keywords"))
+		assert.Len(t, schema.Servers, 6)
+	})
+
+	t.Run("writes a file per key", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, ew.GenerateOpenApiJsonPerVersion(dir, OpenApiGeneratorConfig{OpenApiVersion: OpenApiVersion31}))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		assert.ElementsMatch(t, []string{
+			"v1.json", "guest-v1.json", "manager-v1.json",
+			"v2.json", "guest-v2.json", "manager-v2.json",
+		}, names)
+
+		bs, err := os.ReadFile(filepath.Join(dir, "manager-v2.json"))
+		require.NoError(t, err)
+		var doc struct {
+			Components struct {
+				Schemas map[string]any `json:"schemas"`
+			} `json:"components"`
+		}
+		require.NoError(t, json.Unmarshal(bs, &doc))
+		assert.Contains(t, doc.Components.Schemas, "SampleModel")
+		assert.NotContains(t, doc.Components.Schemas, "keywordSample")
+	})
+}
+
+func TestGenerateOpenApi_SecuritySchemes(t *testing.T) {
+	newWrapper := func() *EchoWrapper {
+		ew := NewEchoWrapper(echo.New())
+		ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000", Dev: "https://dev.hoge.com", Prod: "https://hoge.com"}})
+		sampleHandler := NewSampleHandler()
+		ew.GET("/public", sampleHandler.GetWithQuery, Desc{Name: "public"})
+		ew.GET("/bearer", sampleHandler.GetWithQuery, Desc{Name: "bearer", AuthSchema: NewBearerAuthSchema()})
+		ew.GET("/apikey", sampleHandler.GetWithQuery, Desc{Name: "apiKey", AuthSchema: NewApiKeyAuthSchema()})
+		ew.GroupWithOptions("/users", GroupOptions{AuthSchema: NewBearerAuthSchema()}).
+			GET("/signup", sampleHandler.GetWithQuery, Desc{Name: "signup", AuthSchema: NewNoneAuthSchema()})
+		return ew
+	}
+
+	t.Run("per AuthSchema", func(t *testing.T) {
+		schema, err := newWrapper().endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{Title: "API", Version: "v1"})
+		require.NoError(t, err)
+
+		bearerName := NewBearerAuthSchema().securitySchemeName()
+		apiKeyName := NewApiKeyAuthSchema().securitySchemeName()
+		require.Len(t, schema.Components.SecuritySchemes, 2)
+		assert.Equal(t, &openapi3.SecurityScheme{Type: "http", Scheme: "bearer"}, schema.Components.SecuritySchemes[bearerName].Value)
+		assert.Equal(t, &openapi3.SecurityScheme{Type: "apiKey", Name: "X-Access-Token", In: "header"}, schema.Components.SecuritySchemes[apiKeyName].Value)
+
+		assert.Equal(t, openapi3.SecurityRequirements{}, *schema.Paths.Find("/public").Get.Security)
+		assert.Equal(t, openapi3.SecurityRequirements{{bearerName: []string{}}}, *schema.Paths.Find("/bearer").Get.Security)
+		assert.Equal(t, openapi3.SecurityRequirements{{apiKeyName: []string{}}}, *schema.Paths.Find("/apikey").Get.Security)
+
+		require.NoError(t, schema.Validate(context.Background()))
+	})
+
+	t.Run("AuthHeader applies to endpoints without AuthSchema", func(t *testing.T) {
+		schema, err := newWrapper().endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{AuthHeader: "X-Api-Key"})
+		require.NoError(t, err)
+
+		require.Len(t, schema.Components.SecuritySchemes, 3)
+		assert.Equal(t, &openapi3.SecurityScheme{Type: "apiKey", Name: "X-Api-Key", In: "header"}, schema.Components.SecuritySchemes["auth"].Value)
+		assert.Equal(t, openapi3.SecurityRequirements{{"auth": []string{}}}, *schema.Paths.Find("/public").Get.Security)
+	})
+
+	t.Run("NewNoneAuthSchema requires no authentication", func(t *testing.T) {
+		ew := newWrapper()
+		for _, config := range []OpenApiGeneratorConfig{{}, {AuthHeader: "X-Api-Key"}} {
+			schema, err := ew.endpoints.generateOpenApiSchema(config)
+			require.NoError(t, err)
+			assert.Equal(t, openapi3.SecurityRequirements{}, *schema.Paths.Find("/users/signup").Get.Security, config.AuthHeader)
+		}
+
+		for _, api := range ew.endpoints.api {
+			if api.Name == "signup" {
+				assert.Equal(t, AuthSchema{}, api.generatedApi(nil).AuthSchema)
+			}
+		}
+	})
+}
+
+// TestTypedHandler_Status verifies that the status declared in Desc is sent at runtime
+// and recorded in both .endpoints.json and OpenAPI.
+func TestTypedHandler_Status(t *testing.T) {
+	e := echo.New()
+	e.Validator = noopValidator{}
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+
+	EwPOST(ew, "/samples", func(c *echo.Context, req CreateSampleInput) (CreateSampleOutput, error) {
+		return CreateSampleOutput{ID: req.Name}, nil
+	}, Desc{Name: "createSample", Status: http.StatusCreated})
+	samples := ew.Group("/samples")
+	GwPOSTNoContent(samples, "/:id/archive", func(c *echo.Context, req SampleModel) error {
+		return nil
+	}, Desc{Name: "archiveSample", Status: http.StatusAccepted})
+	GwPATCHNoContent(samples, "/:id", func(c *echo.Context, req SampleModel) error {
+		return nil
+	}, Desc{Name: "patchSample"})
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := serve(http.MethodPost, "/samples", `{"name": "foo", "created_at": 1}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, `{"id": "foo"}`, rec.Body.String())
+	assert.Equal(t, http.StatusAccepted, serve(http.MethodPost, "/samples/1/archive", `{}`).Code)
+	assert.Equal(t, http.StatusNoContent, serve(http.MethodPatch, "/samples/1", `{}`).Code)
+
+	actual, err := ew.endpoints.generateJson()
+	require.NoError(t, err)
+	var result map[string]struct {
+		API map[string]map[string]any `json:"api"`
+	}
+	require.NoError(t, json.Unmarshal(actual, &result))
+	assert.Equal(t, float64(http.StatusCreated), result["v1"].API["createSample"]["status"])
+	assert.Equal(t, float64(http.StatusAccepted), result["v1"].API["archiveSample"]["status"])
+	assert.Equal(t, float64(http.StatusNoContent), result["v1"].API["patchSample"]["status"])
+
+	schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+	require.NoError(t, err)
+	created := schema.Paths.Find("/samples").Post.Responses
+	require.NotNil(t, created.Status(http.StatusCreated))
+	assert.Nil(t, created.Status(http.StatusOK))
+	assert.NotNil(t, created.Status(http.StatusCreated).Value.Content.Get("application/json"))
+	assert.NotNil(t, schema.Paths.Find("/samples/{id}/archive").Post.Responses.Status(http.StatusAccepted))
+	noContent := schema.Paths.Find("/samples/{id}").Patch.Responses.Status(http.StatusNoContent)
+	require.NotNil(t, noContent)
+	assert.Nil(t, noContent.Value.Content)
+}
+
+type sampleNotFoundError struct {
+	Message string `json:"message"`
+}
+
+type sampleConflictError struct {
+	Message    string `json:"message"`
+	ExistingID string `json:"existing_id"`
+}
+
+func TestGenerate_ErrorResponses(t *testing.T) {
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples", sampleHandler.GetWithQuery, Desc{
+		Name: "createSample",
+		Errors: []ErrorResponse{
+			{Status: http.StatusNotFound, Body: sampleNotFoundError{}, Desc: "親のサンプルが存在しない"},
+			{Status: http.StatusConflict, Body: sampleConflictError{}, Desc: "同じ名前のサンプルが存在する"},
+			{Status: http.StatusConflict, Body: sampleNotFoundError{}, Desc: "処理中のサンプルが存在する"},
+			{Status: http.StatusUnauthorized},
+		},
+	}, CreateSampleInput{}, CreateSampleOutput{})
+
+	t.Run("endpoints.json", func(t *testing.T) {
+		actual, err := ew.endpoints.generateJson()
+		require.NoError(t, err)
+		var result map[string]struct {
+			API map[string]struct {
+				Errors []map[string]any `json:"errors"`
+			} `json:"api"`
+		}
+		require.NoError(t, json.Unmarshal(actual, &result))
+		errs := result["v1"].API["createSample"].Errors
+		require.Len(t, errs, 4)
+		assert.Equal(t, map[string]any{
+			"status": float64(http.StatusNotFound),
+			"desc":   "親のサンプルが存在しない",
+			"body":   map[string]any{"$ref": "#/$defs/sampleNotFoundError"},
+		}, errs[0])
+		assert.Nil(t, errs[3]["body"])
+
+		var defs struct {
+			Defs map[string]any `json:"$defs"`
+		}
+		require.NoError(t, json.Unmarshal(actual, &defs))
+		assert.Contains(t, defs.Defs, "sampleNotFoundError")
+		assert.Contains(t, defs.Defs, "sampleConflictError")
+	})
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+		require.NoError(t, err)
+		assert.Contains(t, schema.Components.Schemas, "sampleNotFoundError")
+		assert.Contains(t, schema.Components.Schemas, "sampleConflictError")
+
+		responses := schema.Paths.Find("/samples").Post.Responses
+		assert.NotNil(t, responses.Status(http.StatusOK))
+
+		notFound := responses.Status(http.StatusNotFound).Value
+		assert.Equal(t, "親のサンプルが存在しない", *notFound.Description)
+		assert.Equal(t, "#/components/schemas/sampleNotFoundError", notFound.Content.Get("application/json").Schema.Ref)
+
+		conflict := responses.Status(http.StatusConflict).Value
+		assert.Equal(t, "同じ名前のサンプルが存在する\n処理中のサンプルが存在する", *conflict.Description)
+		oneOf := conflict.Content.Get("application/json").Schema.Value.OneOf
+		require.Len(t, oneOf, 2)
+		assert.Equal(t, "#/components/schemas/sampleConflictError", oneOf[0].Ref)
+		assert.Equal(t, "#/components/schemas/sampleNotFoundError", oneOf[1].Ref)
+
+		unauthorized := responses.Status(http.StatusUnauthorized).Value
+		assert.Equal(t, "Unauthorized", *unauthorized.Description)
+		assert.Nil(t, unauthorized.Content)
+	})
+}
+
+func TestProblemHTTPErrorHandler(t *testing.T) {
+	errSampleNotFound := ErrorCode{Code: "sample_not_found", Status: http.StatusNotFound, Title: "サンプルが存在しない"}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemHTTPErrorHandler
+	e.GET("/code", func(c *echo.Context) error {
+		return fmt.Errorf("wrapped: %w", errSampleNotFound)
+	})
+	e.GET("/problem", func(c *echo.Context) error {
+		return errSampleNotFound.Problem("id=1")
+	})
+	e.GET("/http", func(c *echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid page")
+	})
+	e.GET("/internal", func(c *echo.Context) error {
+		return errors.New("database is down")
+	})
+	e.GET("/untitled", func(c *echo.Context) error {
+		return ErrorCode{Code: "sample_gone", Status: http.StatusGone}
+	})
+	e.GET("/untitled-problem", func(c *echo.Context) error {
+		return &Problem{Status: http.StatusConflict, Detail: "locked"}
+	})
+
+	tests := []struct {
+		path     string
+		status   int
+		expected string
+	}{
+		{"/code", http.StatusNotFound, `{"type": "about:blank", "title": "サンプルが存在しない", "status": 404, "code": "sample_not_found"}`},
+		{"/problem", http.StatusNotFound, `{"type": "about:blank", "title": "サンプルが存在しない", "status": 404, "detail": "id=1", "code": "sample_not_found"}`},
+		{"/http", http.StatusBadRequest, `{"type": "about:blank", "title": "Bad Request", "status": 400, "detail": "invalid page"}`},
+		{"/internal", http.StatusInternalServerError, `{"type": "about:blank", "title": "Internal Server Error", "status": 500}`},
+		{"/missing", http.StatusNotFound, `{"type": "about:blank", "title": "Not Found", "status": 404}`},
+		{"/untitled", http.StatusGone, `{"type": "about:blank", "title": "Gone", "status": 410, "code": "sample_gone"}`},
+		{"/untitled-problem", http.StatusConflict, `{"title": "Conflict", "status": 409, "detail": "locked"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			assert.JSONEq(t, tt.expected, rec.Body.String())
+		})
+	}
+}
+
+func TestGenerate_ErrorCatalog(t *testing.T) {
+	errSampleNotFound := ErrorCode{Code: "sample_not_found", Status: http.StatusNotFound, Title: "サンプルが存在しない"}
+	errSampleLocked := ErrorCode{Code: "sample_locked", Status: http.StatusConflict, Title: "サンプルがロックされている"}
+	errSampleArchived := ErrorCode{Code: "sample_archived", Status: http.StatusConflict, Title: "サンプルがアーカイブされている"}
+
+	e := echo.New()
+	ew := NewEchoWrapper(e)
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	ew.AddErrorCodes(errSampleNotFound, errSampleLocked, errSampleArchived)
+	sampleHandler := NewSampleHandler()
+	ew.PUTTyped("/samples/:id", sampleHandler.GetWithQuery, Desc{
+		Name:   "updateSample",
+		Errors: []ErrorResponse{errSampleNotFound.Response(), errSampleLocked.Response(), errSampleArchived.Response()},
+	}, CreateSampleInput{}, CreateSampleOutput{})
+
+	t.Run("endpoints.json", func(t *testing.T) {
+		actual, err := ew.endpoints.generateJson()
+		require.NoError(t, err)
+		var result struct {
+			Errors []map[string]any `json:"$errors"`
+			Defs   map[string]any   `json:"$defs"`
+			V1     struct {
+				API map[string]struct {
+					Errors []map[string]any `json:"errors"`
+				} `json:"api"`
+			} `json:"v1"`
+		}
+		require.NoError(t, json.Unmarshal(actual, &result))
+		assert.Equal(t, []map[string]any{
+			{"code": "sample_not_found", "status": float64(404), "title": "サンプルが存在しない"},
+			{"code": "sample_locked", "status": float64(409), "title": "サンプルがロックされている"},
+			{"code": "sample_archived", "status": float64(409), "title": "サンプルがアーカイブされている"},
+		}, result.Errors)
+		assert.Contains(t, result.Defs, "Problem")
+
+		errs := result.V1.API["updateSample"].Errors
+		require.Len(t, errs, 3)
+		assert.Equal(t, "sample_not_found", errs[0]["code"])
+		assert.Equal(t, map[string]any{"$ref": "#/$defs/Problem"}, errs[0]["body"])
+	})
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		schema, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+		require.NoError(t, err)
+		assert.Contains(t, schema.Components.Schemas, "Problem")
+		require.Len(t, schema.Components.Responses, 3)
+
+		notFound := schema.Components.Responses["sample_not_found"].Value
+		assert.Equal(t, "サンプルが存在しない", *notFound.Description)
+		allOf := notFound.Content.Get(MIMEApplicationProblemJSON).Schema.Value.AllOf
+		require.Len(t, allOf, 2)
+		assert.Equal(t, "#/components/schemas/Problem", allOf[0].Ref)
+		assert.Equal(t, []any{"sample_not_found"}, allOf[1].Value.Properties["code"].Value.Enum)
+
+		responses := schema.Paths.Find("/samples/{id}").Put.Responses
+		assert.Equal(t, "#/components/responses/sample_not_found", responses.Status(http.StatusNotFound).Ref)
+
+		conflict := responses.Status(http.StatusConflict).Value
+		assert.Equal(t, "サンプルがロックされている\nサンプルがアーカイブされている", *conflict.Description)
+		assert.Equal(t, "#/components/schemas/Problem", conflict.Content.Get(MIMEApplicationProblemJSON).Schema.Ref)
+	})
+
+	t.Run("duplicate code", func(t *testing.T) {
+		ew.AddErrorCodes(errSampleLocked)
+		assert.EqualError(t, ew.endpoints.validate(), "duplicate error code: sample_locked")
+	})
+}
+
+type validatedSampleInput struct {
+	ID    int          `param:"id" jsonschema:"minimum=1"`
+	Page  int          `query:"page" jsonschema:"required,minimum=1"`
+	Sort  string       `query:"sort" jsonschema:"enum=asc,enum=desc"`
+	Name  string       `json:"name" jsonschema:"minLength=1,maxLength=5"`
+	Email string       `json:"email" jsonschema:"format=email"`
+	Code  string       `json:"code,omitempty" jsonschema:"pattern=^[A-Z]{3}$"`
+	Ratio float64      `json:"ratio,omitempty" jsonschema:"exclusiveMinimum=0,maximum=1"`
+	Kind  string       `json:"kind,omitempty" jsonschema:"enum=a,enum=b"`
+	Tags  []string     `json:"tags,omitempty" jsonschema:"maxItems=2"`
+	Owner *SampleModel `json:"owner,omitempty"`
+}
+
+// TestDefaultValidator verifies that typed handlers validate requests against the published
+// schema when no Echo Validator is registered.
+func TestDefaultValidator(t *testing.T) {
+	newServer := func(validator echo.Validator) *echo.Echo {
+		e := echo.New()
+		e.Validator = validator
+		ew := NewEchoWrapper(e)
+		EwPOST(ew, "/samples/:id", func(c *echo.Context, req validatedSampleInput) (CreateSampleOutput, error) {
+			return CreateSampleOutput{ID: req.Name}, nil
+		}, Desc{Name: "createSample"})
+		return e
+	}
+	serve := func(e *echo.Echo, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	valid := `{"name": "foo", "email": "foo@example.com"}`
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		message string
+	}{
+		{"valid", "/samples/1?page=1&sort=asc", `{"name": "foo", "email": "foo@example.com", "code": "ABC", "ratio": 1, "tags": ["x"], "owner": {"id": "1", "name": "o", "created_at": 0}}`, ""},
+		{"missing required query", "/samples/1", valid, "invalid query parameter: page: is required"},
+		{"query below minimum", "/samples/1?page=0", valid, "invalid query parameter: page: must be greater than or equal to 1"},
+		{"query not in enum", "/samples/1?page=1&sort=up", valid, "invalid query parameter: sort: must be one of [asc desc]"},
+		{"path below minimum", "/samples/0?page=1", valid, "invalid path parameter: id: must be greater than or equal to 1"},
+		{"missing required body field", "/samples/1?page=1", `{"name": "foo"}`, "email: is required"},
+		{"too long", "/samples/1?page=1", `{"name": "foobar", "email": "foo@example.com"}`, "name: must be at most 5 characters"},
+		{"invalid format", "/samples/1?page=1", `{"name": "foo", "email": "foo"}`, "email: must be a valid email"},
+		{"pattern mismatch", "/samples/1?page=1", `{"name": "foo", "email": "foo@example.com", "code": "abc"}`, `code: must match the pattern "^[A-Z]{3}$"`},
+		{"exclusive minimum", "/samples/1?page=1", `{"name": "foo", "email": "foo@example.com", "ratio": 0}`, "ratio: must be greater than 0"},
+		{"body not in enum", "/samples/1?page=1", `{"name": "foo", "email": "foo@example.com", "kind": "c"}`, "kind: must be one of [a b]"},
+		{"too many items", "/samples/1?page=1", `{"name": "foo", "email": "foo@example.com", "tags": ["a", "b", "c"]}`, "tags: must have at most 2 items"},
+		{"nested", "/samples/1?page=1", `{"name": "foo", "email": "foo@example.com", "owner": {"id": "1", "name": "o"}}`, "owner.created_at: is required"},
+		{"wrong type", "/samples/1?page=1", `{"name": 1, "email": "foo@example.com"}`, ""},
+	}
+	e := newServer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(e, tt.path, tt.body)
+			if tt.name == "valid" {
+				assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			if tt.message != "" {
+				assert.JSONEq(t, fmt.Sprintf(`{"message": %q}`, tt.message), rec.Body.String())
+			}
+		})
+	}
+
+	t.Run("registered validator takes precedence", func(t *testing.T) {
+		rec := serve(newServer(noopValidator{}), "/samples/0", `{}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+type validateTagsInput struct {
+	Page     int            `query:"page" validate:"required,gte=1"`
+	Name     string         `json:"name,omitempty" validate:"required,min=1,max=100"`
+	Kind     string         `json:"kind" validate:"oneof=a b"`
+	Level    int            `json:"level" validate:"oneof=1 2 3,lt=10"`
+	Email    string         `json:"email" validate:"omitempty,email"`
+	Tags     []string       `json:"tags" validate:"max=3,unique,dive,alphanum,len=4"`
+	Ratio    float64        `json:"ratio" validate:"gt=0,lte=1"`
+	Labels   map[string]int `json:"labels" validate:"min=1"`
+	Optional *string        `json:"optional,omitempty" validate:"omitempty,uuid4"`
+	Either   string         `json:"either" validate:"email|url"`
+	Nickname string         `json:"nickname" validate:"omitempty,min=3,max=20"`
+	Title    string         `json:"title" validate:"required"`
+	Members  []string       `json:"members" validate:"required"`
+}
+
+func TestReflectType_ValidateTags(t *testing.T) {
+	schema, _ := reflectType(validateTagsInput{})
+	def := schema.Definitions[strings.TrimPrefix(schema.Ref, "#/$defs/")]
+	require.NotNil(t, def)
+	prop := func(name string) *jsonschema.Schema {
+		p, ok := def.Properties.Get(name)
+		require.True(t, ok, name)
+		return p
+	}
+
+	assert.Contains(t, def.Required, "name")
+	assert.NotContains(t, def.Required, "optional")
+	assert.Equal(t, uint64(1), *prop("name").MinLength)
+	assert.Equal(t, uint64(100), *prop("name").MaxLength)
+	assert.Equal(t, []any{"a", "b"}, prop("kind").Enum)
+	assert.Equal(t, []any{int64(1), int64(2), int64(3)}, prop("level").Enum)
+	assert.Equal(t, json.Number("10"), prop("level").ExclusiveMaximum)
+	assert.Empty(t, prop("email").Format, "an empty email skips the format check")
+	assert.Nil(t, prop("nickname").MinLength, "an empty nickname skips min")
+	assert.Equal(t, uint64(20), *prop("nickname").MaxLength)
+	assert.Equal(t, uint64(1), *prop("title").MinLength, "required rejects the empty string")
+	assert.Equal(t, uint64(1), *prop("members").MinItems, "required rejects the empty slice")
+	assert.Equal(t, uint64(3), *prop("tags").MaxItems)
+	assert.True(t, prop("tags").UniqueItems)
+	assert.Equal(t, "^[a-zA-Z0-9]+$", prop("tags").Items.Pattern)
+	assert.Equal(t, uint64(4), *prop("tags").Items.MinLength)
+	assert.Equal(t, uint64(4), *prop("tags").Items.MaxLength)
+	assert.Equal(t, json.Number("0"), prop("ratio").ExclusiveMinimum)
+	assert.Equal(t, json.Number("1"), prop("ratio").Maximum)
+	assert.Equal(t, uint64(1), *prop("labels").MinProperties)
+	assert.Equal(t, "uuid", prop("optional").Format)
+	assert.Empty(t, prop("either").Format)
+
+	query := parameterSchema(validateTagsInput{}, "query", nil)
+	require.NotNil(t, query)
+	assert.Equal(t, []string{"page"}, query.Required)
+	page, ok := query.Properties.Get("page")
+	require.True(t, ok)
+	assert.Equal(t, json.Number("1"), page.Minimum)
+
+	t.Run("OpenAPI", func(t *testing.T) {
+		ew := NewEchoWrapper(echo.New())
+		ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+		ew.POSTTyped("/samples", NewSampleHandler().GetWithQuery, Desc{Name: "createSample"}, validateTagsInput{}, CreateSampleOutput{})
+
+		doc, err := ew.endpoints.generateOpenApiSchema(OpenApiGeneratorConfig{})
+		require.NoError(t, err)
+		input := doc.Components.Schemas["validateTagsInput"].Value
+		assert.Contains(t, input.Required, "name")
+		assert.Equal(t, uint64(100), *input.Properties["name"].Value.MaxLength)
+		assert.Equal(t, []any{"a", "b"}, input.Properties["kind"].Value.Enum)
+
+		page := doc.Paths.Find("/samples").Post.Parameters.GetByInAndName("query", "page")
+		require.NotNil(t, page)
+		assert.True(t, page.Required)
+		assert.Equal(t, float64(1), *page.Schema.Value.Min)
+	})
+}
+
+// unsetSampleOutput is returned with its slice, pointer and map fields left nil.
+type unsetSampleOutput struct {
+	Tags   []string          `json:"tags"`
+	Note   *string           `json:"note"`
+	Owner  *SampleModel      `json:"owner"`
+	Labels map[string]string `json:"labels"`
+	Memo   []string          `json:"memo,omitempty"`
+}
+
+func TestEchoWrapper_ResponseValidator(t *testing.T) {
+	newServer := func(config ResponseValidatorConfig) *echo.Echo {
+		e := echo.New()
+		ew := NewEchoWrapper(e)
+		e.Use(ew.ResponseValidator(config))
+		samples := ew.Group("/samples")
+		samples.GETTyped("/:id", func(c *echo.Context) error {
+			switch c.Param("id") {
+			case "broken":
+				return c.JSON(http.StatusOK, map[string]any{"id": "broken", "name": nil, "created_at": 0})
+			case "extra":
+				return c.JSON(http.StatusOK, map[string]any{"id": "extra", "name": "foo", "created_at": 0, "secret": "x"})
+			}
+			return c.JSON(http.StatusOK, SampleModel{ID: c.Param("id"), Name: "foo"})
+		}, Desc{Name: "getSample", Query: "expand=owner"}, SampleModel{})
+		ew.POSTTyped("/samples", func(c *echo.Context) error {
+			return c.JSON(http.StatusOK, CreateSampleOutput{ID: "1"})
+		}, Desc{Name: "createSample", Status: http.StatusCreated}, CreateSampleInput{}, CreateSampleOutput{})
+		ew.GETTyped("/missing", func(c *echo.Context) error {
+			return echo.NewHTTPError(http.StatusNotFound, "not found")
+		}, Desc{Name: "missing"}, SampleModel{})
+		ew.GETTyped("/unset", func(c *echo.Context) error {
+			return c.JSON(http.StatusOK, unsetSampleOutput{})
+		}, Desc{Name: "getUnset"}, unsetSampleOutput{})
+		ew.GETTyped("/unset-list", func(c *echo.Context) error {
+			return c.JSON(http.StatusOK, []unsetSampleOutput(nil))
+		}, Desc{Name: "getUnsetList"}, []unsetSampleOutput{})
+		return e
+	}
+	serve := func(e *echo.Echo, method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		var mismatches []string
+		e := newServer(ResponseValidatorConfig{
+			Fail: true,
+			OnMismatch: func(c *echo.Context, api API, err error) {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %v", api.Name, err))
+			},
+		})
+
+		rec := serve(e, http.MethodGet, "/samples/1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"id": "1", "name": "foo", "created_at": 0}`, rec.Body.String())
+
+		rec = serve(e, http.MethodGet, "/samples/broken")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"message": "name: must be string"}`, rec.Body.String())
+
+		rec = serve(e, http.MethodGet, "/samples/extra")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assert.JSONEq(t, `{"message": "secret: is not allowed"}`, rec.Body.String())
+
+		rec = serve(e, http.MethodPost, "/samples")
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		rec = serve(e, http.MethodGet, "/missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		// nilのスライスやポインタはnullとして返される
+		rec = serve(e, http.MethodGet, "/unset")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"tags": null, "note": null, "owner": null, "labels": null}`, rec.Body.String())
+		rec = serve(e, http.MethodGet, "/unset-list")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "null\n", rec.Body.String())
+
+		assert.Equal(t, []string{
+			"getSample: name: must be string",
+			"getSample: secret: is not allowed",
+			"createSample: status 200 does not match the declared status 201",
+		}, mismatches)
+	})
+
+	t.Run("report only", func(t *testing.T) {
+		var mismatches int
+		e := newServer(ResponseValidatorConfig{
+			OnMismatch: func(c *echo.Context, api API, err error) {
+				mismatches++
+			},
+		})
+
+		rec := serve(e, http.MethodGet, "/samples/broken")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"id": "broken", "name": null, "created_at": 0}`, rec.Body.String())
+		assert.Equal(t, 1, mismatches)
+	})
+}
+
+// TestGenerateTypeScript verifies that every $defs entry is emitted as a TypeScript type under its
+// merged name, and that each .endpoints.json key gets a fetch client keyed by Desc.Name.
+func TestGenerateTypeScript(t *testing.T) {
+	ew := NewEchoWrapper(echo.New())
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000", Prod: "https://example.com"}})
+	ew.AddFrontends("guest")
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples/:id/search", sampleHandler.GetWithQuery, Desc{
+		Name: "searchSamples",
+		Desc: "search samples",
+	}, SearchSamplesInput{}, GetAllSamplesOutput{})
+	ew.POSTTyped("/prices", sampleHandler.GetWithQuery, Desc{
+		Name:       "createPrices",
+		Frontends:  []string{"manager"},
+		Deprecated: true,
+	}, mixedPricesRequest{}, []collision_b.Price{})
+	ew.DELETETyped("/samples/:id", sampleHandler.GetWithQuery, Desc{
+		Name:   "deleteSample",
+		Query:  "force=true",
+		Status: http.StatusNoContent,
+	}, nil, SampleModel{})
+
+	actual, err := ew.endpoints.generateTypeScript()
+	require.NoError(t, err)
+	ts := string(actual)
+
+	collisionA := qualifiedTypeName(reflect.TypeOf(collision_a.Price{}))
+	collisionB := qualifiedTypeName(reflect.TypeOf(collision_b.Price{}))
+	assert.Contains(t, ts, `export interface SearchSamplesInput {
+  name: string;
+}`)
+	assert.Contains(t, ts, `export interface GetAllSamplesOutput {
+  samples: SampleModel[];
+  total: number;
+}`)
+	assert.Contains(t, ts, fmt.Sprintf(`export interface mixedPricesRequest {
+  price_a: %s;
+  price_b: %s;
+}`, collisionA, collisionB))
+	assert.Contains(t, ts, "export interface "+collisionA+" {")
+	assert.Contains(t, ts, "export interface "+collisionB+" {")
+
+	assert.Contains(t, ts, `export const createV1Client = (options: ClientOptions = {}) => ({
+  /** search samples */
+  searchSamples: (path: { id: number }, body: SearchSamplesInput, query: { page: number; sort?: "asc" | "desc"; tags?: string[] }, headers?: { "Accept-Language"?: string }, init?: RequestInit) =>
+    request<GetAllSamplesOutput>(options, v1Env, "POST", `+"`/samples/${pathParam(path.id)}/search`"+`, query, headers, body, init),
+  /** @deprecated */
+  createPrices: (body: mixedPricesRequest, init?: RequestInit) =>
+    request<`+collisionB+`[]>(options, v1Env, "POST", `+"`/prices`"+`, undefined, undefined, body, init),
+  deleteSample: (path: { id: string }, query: { force: string }, init?: RequestInit) =>
+    request<void>(options, v1Env, "DELETE", `+"`/samples/${pathParam(path.id)}`"+`, query, undefined, undefined, init),
+});`)
+	assert.Contains(t, ts, `const guestV1Env: Record<Stage, string> = {
+  local: "http://localhost:8000",
+  localDev: "",
+  dev: "",
+  prod: "https://example.com",
+};`)
+	guest := ts[strings.Index(ts, "export const createGuestV1Client"):]
+	assert.Contains(t, guest, "searchSamples:")
+	assert.NotContains(t, guest, "createPrices:")
+
+	assert.EqualError(t, checkIdentifiers([]string{"a-b", "a_b"}, typeScriptIdentifier), `"a-b" and "a_b" are both emitted as the identifier a_b`)
+	ew.AddFrontends("guest_app", "guest-app")
+	_, err = ew.endpoints.generateTypeScript()
+	assert.EqualError(t, err, `"guest_app-v1" and "guest-app-v1" are both emitted as the identifier GuestAppV1`)
+}
+
+type sampleTree struct {
+	Name     string       `json:"name"`
+	Children []sampleTree `json:"children"`
+}
+
+// TestGenerateZod verifies that $defs are emitted as Zod schemas in dependency order,
+// and that each .endpoints.json key maps endpoints to their request and response schemas.
+func TestGenerateZod(t *testing.T) {
+	ew := NewEchoWrapper(echo.New())
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	ew.AddFrontends("guest")
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/prices", sampleHandler.GetWithQuery, Desc{
+		Name:      "createPrices",
+		Frontends: []string{"manager"},
+	}, collision_a.MapPriceRequest{}, []collision_b.Price{})
+	ew.GETTyped("/keywords", sampleHandler.GetWithQuery, Desc{Name: "getKeywords"}, keywordSample{})
+	ew.GETTyped("/tree", sampleHandler.GetWithQuery, Desc{Name: "getTree"}, sampleTree{})
+
+	actual, err := ew.endpoints.generateZod()
+	require.NoError(t, err)
+	zod := string(actual)
+
+	collisionA := qualifiedTypeName(reflect.TypeOf(collision_a.Price{}))
+	collisionB := qualifiedTypeName(reflect.TypeOf(collision_b.Price{}))
+	assert.Contains(t, zod, `export const MapPriceRequestSchema = z.object({
+  items: z.record(z.string(), `+collisionA+`Schema),
+});
+export type MapPriceRequest = z.infer<typeof MapPriceRequestSchema>;`)
+	assert.Less(t, strings.Index(zod, "export const "+collisionA+"Schema"), strings.Index(zod, "export const MapPriceRequestSchema"),
+		"referenced schemas should be declared first")
+	assert.Contains(t, zod, `  status: z.enum(["active", "inactive"]),
+  kind: sampleKindSchema,
+  created_at: z.string().datetime({ offset: true }),
+  email: z.string().min(3).max(254).regex(new RegExp("^.+@.+$")).email(),
+  age: z.number().int().gte(0).lte(150),
+  ratio: z.number().gt(0).lt(1),
+  tags: z.array(z.string()).min(1).max(5),
+  nickname: z.string().nullable(),
+  model: SampleModelSchema.nullable(),
+  id: z.union([z.string(), z.number().int()]),`)
+	assert.Contains(t, zod, `export const sampleTreeSchema: z.ZodTypeAny = z.object({
+  name: z.string(),
+  children: z.array(z.lazy(() => sampleTreeSchema)),
+});`)
+
+	assert.Contains(t, zod, `export const endpoints = {
+  v1: {
+    createPrices: {
+      method: "POST",
+      path: "prices",
+      request: MapPriceRequestSchema,
+      response: z.array(`+collisionB+`Schema),
+    },`)
+	guest := zod[strings.Index(zod, `"guest-v1": {`):]
+	assert.Contains(t, guest, `    getTree: {
+      method: "GET",
+      path: "tree",
+      request: z.void(),
+      response: sampleTreeSchema,
+    },`)
+	assert.NotContains(t, guest, "createPrices")
+
+	ew.GETTyped("/boxes", sampleHandler.GetWithQuery, Desc{Name: "getBoxes"}, sampleBox[int]{})
+	ew.GETTyped("/legacy-boxes", sampleHandler.GetWithQuery, Desc{Name: "getLegacyBoxes"}, sampleBox_int_{})
+	_, err = ew.endpoints.generateZod()
+	assert.EqualError(t, err, `"sampleBox[int]" and "sampleBox_int_" are both emitted as the identifier sampleBox_int_`)
+}
+
+type sampleBox[T any] struct {
+	Value T `json:"value"`
+}
+
+// sampleBox_int_ is named to collide with the TypeScript identifier of sampleBox[int].
+type sampleBox_int_ struct {
+	Value int `json:"value"`
+}
+
+// TestGenerateGoClient verifies that the generated client has one method per Desc.Name using the
+// registered types, and builds the path, query, headers and auth header from the API definition.
+func TestGenerateGoClient(t *testing.T) {
+	ew := NewEchoWrapper(echo.New())
+	ew.AddEnv(
+		Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000", Prod: "https://example.com"}},
+		Env{Version: "v2", Domain: Domain{Local: "http://localhost:8000", Prod: "https://v2.example.com"}},
+	)
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples/:id/search", sampleHandler.GetWithQuery, Desc{
+		Name:       "searchSamples",
+		Desc:       "search samples",
+		AuthSchema: NewBearerAuthSchema(),
+	}, goclient.SearchInput{}, []collision_b.Price{})
+	ew.GETTyped("/samples/:id", sampleHandler.GetWithQuery, Desc{
+		Name:       "getSample",
+		Query:      "expand=owner",
+		Deprecated: true,
+	}, goclient.Sample{})
+	ew.GETTyped("/types/:type/:ctx/:req", sampleHandler.GetWithQuery, Desc{Name: "getByType"}, goclient.Sample{})
+	ew.DELETETyped("/samples/:id", sampleHandler.GetWithQuery, Desc{
+		Name:     "deleteSample",
+		Status:   http.StatusNoContent,
+		Versions: []string{"v2"},
+	}, nil, nil)
+
+	_, err := ew.endpoints.generateGoClient(GoClientGeneratorConfig{Package: "sampleclient"})
+	assert.EqualError(t, err, "version is required when 2 envs are registered")
+
+	actual, err := ew.endpoints.generateGoClient(GoClientGeneratorConfig{Package: "sampleclient", Version: "v1"})
+	require.NoError(t, err)
+	typeCheckGoClient(t, actual)
+	client := string(actual)
+
+	assert.Contains(t, client, "package sampleclient\n")
+	assert.Contains(t, client, fmt.Sprintf("\tendpoints %q\n", reflect.TypeOf(Domain{}).PkgPath()))
+	assert.Contains(t, client, fmt.Sprintf("\tcollision_b %q\n", reflect.TypeOf(collision_b.Price{}).PkgPath()))
+	assert.Contains(t, client, `		Local:    "http://localhost:8000",
+		LocalDev: "",
+		Dev:      "",
+		Prod:     "https://example.com",`)
+	assert.Contains(t, client, `// SearchSamples は POST samples/:id/search を呼び出す
+// search samples
+func (c *Client) SearchSamples(ctx context.Context, req goclient.SearchInput) ([]collision_b.Price, error) {
+	var resp []collision_b.Price
+	q := url.Values{}
+	addParam(q, "page", req.Page, true)
+	addParam(q, "sort", req.Sort, false)
+	addParam(q, "tags", req.Tags, false)
+	header := http.Header{}
+	addParam(header, "Accept-Language", req.Locale, false)
+	body, err := jsonBody(req, "ID", "Page", "Sort", "Tags", "Locale")
+	if err != nil {
+		return resp, err
+	}
+	err = c.do(ctx, http.MethodPost, "/samples/"+url.PathEscape(fmt.Sprint(req.ID))+"/search", q, header, authScheme{typ: "Bearer", header: "Authorization"}, body, &resp)
+	return resp, err
+}`)
+	assert.Contains(t, client, `// GetSample は GET samples/:id?expand=owner を呼び出す
+//
+// Deprecated: このAPIは非推奨です
+func (c *Client) GetSample(ctx context.Context, id string, query url.Values) (goclient.Sample, error) {`)
+	assert.Contains(t, client, `func (c *Client) GetByType(ctx context.Context, typeParam string, ctxParam string, reqParam string) (goclient.Sample, error) {`)
+	assert.NotContains(t, client, "DeleteSample")
+
+	actual, err = ew.endpoints.generateGoClient(GoClientGeneratorConfig{Package: "sampleclient", Version: "v2"})
+	require.NoError(t, err)
+	typeCheckGoClient(t, actual)
+	assert.Contains(t, string(actual), `func (c *Client) DeleteSample(ctx context.Context, id string) error {
+	q := url.Values{}
+	header := http.Header{}
+	return c.do(ctx, http.MethodDelete, "/samples/"+url.PathEscape(id), q, header, authScheme{}, nil, nil)
+}`)
+
+	ew.GETTyped("/users/:id", sampleHandler.GetWithQuery, Desc{Name: "get-sample"}, goclient.Sample{})
+	_, err = ew.endpoints.generateGoClient(GoClientGeneratorConfig{Package: "sampleclient", Version: "v1"})
+	assert.EqualError(t, err, "getSample and get-sample are both generated as the method GetSample")
+
+	ew = NewEchoWrapper(echo.New())
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	ew.GETTyped("/prices", sampleHandler.GetWithQuery, Desc{Name: "getPrices"}, mixedPricesRequest{})
+	_, err = ew.endpoints.generateGoClient(GoClientGeneratorConfig{Package: "sampleclient", Version: "v1"})
+	assert.ErrorContains(t, err, "getPrices: type "+reflect.TypeOf(Domain{}).PkgPath()+".mixedPricesRequest cannot be referenced from another package")
+}
+
+// typeCheckGoClient builds a generated client as a package of this module, without writing it to the module.
+func typeCheckGoClient(t *testing.T, src []byte) {
+	t.Helper()
+	goCmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "client.go")
+	require.NoError(t, os.WriteFile(filename, src, 0o644))
+	overlay, err := json.Marshal(map[string]any{
+		"Replace": map[string]string{filepath.Join(wd, "testfixture", "goclient", "generated", "client.go"): filename},
+	})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "overlay.json"), overlay, 0o644))
+
+	out, err := exec.Command(goCmd, "build", "-overlay", filepath.Join(dir, "overlay.json"), "./testfixture/goclient/generated").CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+// TestParse verifies that a generated .endpoints.json can be read back into typed structs.
+func TestParse(t *testing.T) {
+	ew := newRoute(echo.New())
+	ew.AddFrontends("guest")
+	ew.AddErrorCodes(ErrorCode{Code: "sample_not_found", Status: http.StatusNotFound, Title: "Sample not found"})
+	filename := filepath.Join(t.TempDir(), ".endpoints.json")
+	require.NoError(t, ew.Generate(filename))
+
+	doc, err := Load(filename)
+	require.NoError(t, err)
+
+	var keys []string
+	for _, v := range doc.Versions {
+		keys = append(keys, v.Key)
+	}
+	assert.Equal(t, []string{"v1", "guest-v1", "v2", "guest-v2"}, keys)
+	assert.Equal(t, []ErrorCode{{Code: "sample_not_found", Status: http.StatusNotFound, Title: "Sample not found"}}, doc.Errors)
+
+	v2, ok := doc.Version("v2", "guest")
+	require.True(t, ok)
+	var names []string
+	for _, api := range v2.APIs {
+		names = append(names, api.Name)
+	}
+	assert.Equal(t, []string{"getSamplesWithQuery", "getSamplesWithQueryAnother", "createSample", "getAllSamples", "patchSample"}, names)
+
+	createSample, ok := v2.API("createSample")
+	require.True(t, ok)
+	assert.Equal(t, "samples/:id", createSample.Path)
+	assert.Equal(t, http.MethodPost, createSample.Method)
+	assert.Equal(t, "#/$defs/CreateSampleInput", createSample.Request.Ref)
+	assert.Equal(t, "#/$defs/CreateSampleOutput", createSample.Response.Ref)
+
+	patchSample, ok := v2.API("patchSample")
+	require.True(t, ok)
+	assert.Equal(t, http.StatusNoContent, patchSample.Status)
+
+	def, ok := doc.Def(createSample.Response.Ref)
+	require.True(t, ok)
+	id, ok := def.Properties.Get("id")
+	require.True(t, ok)
+	assert.Equal(t, "string", id.Type)
+
+	url, err := doc.URL("getSamplesWithQuery", "v2", "", StageDev)
+	require.NoError(t, err)
+	assert.Equal(t, "https://v2.dev.hoge.com/samples/:id?yearMonth=2021-01", url)
+
+	_, err = doc.URL("createSample", "v1", "", StageProd)
+	assert.EqualError(t, err, "unknown api: createSample")
+	_, err = doc.URL("getAllSamples", "v3", "guest", StageProd)
+	assert.EqualError(t, err, "unknown version: guest-v3")
+
+	registered, err := ew.Document()
+	require.NoError(t, err)
+	assert.Equal(t, doc, registered)
+}
+
+func TestMockServer(t *testing.T) {
+	ew := newRoute(echo.New())
+	ew.GETTyped("/samples/latest", func(c *echo.Context) error { return nil }, Desc{
+		Name:    "getLatestSample",
+		Desc:    "get the latest sample",
+		Example: SampleModel{ID: "sample-1", Name: "latest", CreatedAt: 1700000000},
+	}, SampleModel{})
+	filename := filepath.Join(t.TempDir(), ".endpoints.json")
+	require.NoError(t, ew.Generate(filename))
+	doc, err := Load(filename)
+	require.NoError(t, err)
+
+	serve := func(mock *echo.Echo, method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mock.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	v1, err := ew.MockServer(MockConfig{})
+	require.NoError(t, err)
+
+	// 明示されたレスポンスの例
+	rec := serve(v1, http.MethodGet, "/samples/latest")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"sample-1","name":"latest","created_at":1700000000}`, rec.Body.String())
+
+	// スキーマから合成したレスポンス
+	rec = serve(v1, http.MethodGet, "/samples")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"samples":[{"id":"string","name":"string","created_at":0}],"total":0}`, rec.Body.String())
+	v, _ := doc.Version("v1", "")
+	api, _ := v.API("getAllSamples")
+	value, err := decodeJSONValue(rec.Body.Bytes())
+	require.NoError(t, err)
+	assert.NoError(t, newSchemaValidator(doc.Defs).validate(api.Response, value, ""))
+
+	// v2にのみ含まれるエンドポイント
+	assert.Equal(t, http.StatusMethodNotAllowed, serve(v1, http.MethodPost, "/samples/1").Code)
+	v2, err := NewMockServer(doc, MockConfig{Version: "v2"})
+	require.NoError(t, err)
+	rec = serve(v2, http.MethodPost, "/samples/1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"string"}`, rec.Body.String())
+	rec = serve(v2, http.MethodPatch, "/samples/1")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	_, err = NewMockServer(doc, MockConfig{Version: "v3"})
+	assert.EqualError(t, err, "unknown version: v3")
+
+	// OpenAPIから読み込んだ場合
+	openapi := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, ew.GenerateOpenApi(openapi, OpenApiGeneratorConfig{Version: "v2"}))
+	doc, err = LoadOpenApi(openapi)
+	require.NoError(t, err)
+	assert.Equal(t, "v2", doc.Versions[0].Key)
+	assert.Equal(t, "https://v2.dev.hoge.com", doc.Versions[0].Env.Dev)
+	fromOpenApi, err := NewMockServer(doc, MockConfig{})
+	require.NoError(t, err)
+	rec = serve(fromOpenApi, http.MethodGet, "/samples/latest")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"sample-1","name":"latest","created_at":1700000000}`, rec.Body.String())
+	rec = serve(fromOpenApi, http.MethodPost, "/samples/1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"string"}`, rec.Body.String())
+	assert.Equal(t, http.StatusNoContent, serve(fromOpenApi, http.MethodPatch, "/samples/1").Code)
+}
+
+func TestParseOpenApi(t *testing.T) {
+	ew := NewEchoWrapper(echo.New())
+	ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000", Dev: "https://dev.example.com"}})
+	sampleHandler := NewSampleHandler()
+	ew.POSTTyped("/samples/:id/search", sampleHandler.GetWithQuery, Desc{
+		Name:       "searchSamples",
+		AuthSchema: NewBearerAuthSchema(),
+	}, goclient.SearchInput{}, []goclient.Sample{})
+	ew.GETTyped("/samples/:id", sampleHandler.GetWithQuery, Desc{
+		Name:       "getSample",
+		AuthSchema: NewApiKeyAuthSchema(),
+	}, goclient.Sample{})
+	ew.GETTyped("/health", sampleHandler.GetWithQuery, Desc{Name: "health"}, nil)
+	openapi := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, ew.GenerateOpenApi(openapi, OpenApiGeneratorConfig{}))
+
+	doc, err := LoadOpenApi(openapi)
+	require.NoError(t, err)
+	require.Len(t, doc.Versions, 1)
+	v := doc.Versions[0]
+	assert.Equal(t, "v1", v.Key)
+	assert.Equal(t, "https://dev.example.com", v.Env.Dev)
+
+	search, ok := v.API("searchSamples")
+	require.True(t, ok)
+	assert.Equal(t, "samples/:id/search", search.Path)
+	assert.Equal(t, NewBearerAuthSchema(), search.AuthSchema)
+	require.NotNil(t, search.Params)
+	assert.Equal(t, []string{"id"}, propertyNames(search.Params.Path))
+	assert.Equal(t, []string{"id"}, search.Params.Path.Required)
+	assert.Equal(t, []string{"page", "sort", "tags"}, propertyNames(search.Params.Query))
+	assert.Equal(t, []string{"page"}, search.Params.Query.Required)
+	order, _ := search.Params.Query.Properties.Get("sort")
+	assert.Equal(t, []any{"asc", "desc"}, order.Enum)
+	assert.Equal(t, []string{"Accept-Language"}, propertyNames(search.Params.Header))
+	assert.Empty(t, search.Params.Header.Required)
+
+	getSample, ok := v.API("getSample")
+	require.True(t, ok)
+	assert.Equal(t, NewApiKeyAuthSchema(), getSample.AuthSchema)
+	assert.Equal(t, []string{"id"}, propertyNames(getSample.Params.Path))
+	assert.Nil(t, getSample.Params.Query)
+
+	health, ok := v.API("health")
+	require.True(t, ok)
+	assert.Equal(t, AuthSchema{}, health.AuthSchema)
+	assert.Nil(t, health.Params)
+
+	// AuthHeaderの認証は、そのヘッダのApiKeyとして読み込まれる
+	require.NoError(t, ew.GenerateOpenApi(openapi, OpenApiGeneratorConfig{AuthHeader: "X-Session"}))
+	doc, err = LoadOpenApi(openapi)
+	require.NoError(t, err)
+	health, _ = doc.Versions[0].API("health")
+	assert.Equal(t, AuthSchema{Type: "ApiKey", Header: "X-Session"}, health.AuthSchema)
+}
+
+func TestServeSpec(t *testing.T) {
+	e := echo.New()
+	ew := newRoute(e)
+	ew.AddFrontends("guest")
+	ew.ServeEndpointsJson("/_endpoints.json")
+	ew.ServeOpenApiJson("/openapi.json", OpenApiGeneratorConfig{})
+	ew.ServeOpenApi("/openapi.yaml", OpenApiGeneratorConfig{})
+
+	get := func(path, etag string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 配信用のハンドラ自体は.endpoints.jsonに含まれない
+	rec := get("/_endpoints.json", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	expected, err := ew.endpoints.generateJson()
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), rec.Body.String())
+
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, "no-cache", rec.Header().Get("Cache-Control"))
+	rec = get("/_endpoints.json", etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	rec = get("/_endpoints.json?version=v2&frontend=guest", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	doc, err := Parse(rec.Body.Bytes())
+	require.NoError(t, err)
+	require.Len(t, doc.Versions, 1)
+	assert.Equal(t, "guest-v2", doc.Versions[0].Key)
+
+	assert.Equal(t, http.StatusNotFound, get("/_endpoints.json?version=v3", "").Code)
+	assert.Equal(t, http.StatusNotFound, get("/openapi.json?frontend=manager", "").Code)
+
+	rec = get("/openapi.json?version=v1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationJSON, rec.Header().Get("Content-Type"))
+	var buf bytes.Buffer
+	require.NoError(t, ew.endpoints.generateOpenApiJson(&buf, OpenApiGeneratorConfig{Version: "v1"}))
+	assert.Equal(t, buf.String(), rec.Body.String())
+
+	rec = get("/openapi.yaml", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/yaml", rec.Header().Get("Content-Type"))
+	doc, err = ParseOpenApi(rec.Body.Bytes())
+	require.NoError(t, err)
+	assert.NotEmpty(t, doc.Versions[0].APIs)
+	assert.Equal(t, http.StatusNotModified, get("/openapi.yaml", rec.Header().Get("ETag")).Code)
+}
+
+func TestServeDocs(t *testing.T) {
+	get := func(e *echo.Echo, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	disabled := echo.New()
+	newRoute(disabled).ServeDocs("/_docs", DocsConfig{})
+	assert.Equal(t, http.StatusNotFound, get(disabled, "/_docs").Code)
+	assert.Equal(t, http.StatusNotFound, get(disabled, "/_docs/openapi.json").Code)
+
+	e := echo.New()
+	ew := newRoute(e)
+	ew.AddFrontends("guest")
+	ew.ServeDocs("/_docs", DocsConfig{Enabled: true, OpenApi: OpenApiGeneratorConfig{Title: "sample"}})
+
+	rec := get(e, "/_docs")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, rec.Body.String(), `"versions":["v1","v2"]`)
+	assert.Contains(t, rec.Body.String(), `"frontends":["guest"]`)
+	assert.NotContains(t, rec.Body.String(), "https://")
+	assert.Equal(t, http.StatusOK, get(e, "/_docs/").Code)
+
+	rec = get(e, "/_docs/openapi.json?version=v2&frontend=guest")
+	require.Equal(t, http.StatusOK, rec.Code)
+	doc, err := ParseOpenApi(rec.Body.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "v2", doc.Versions[0].Key)
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	ew := newRoute(echo.New())
+	ew.POSTTyped("/admin/samples", func(c *echo.Context) error { return nil }, Desc{
+		Name:       "adminCreateSample",
+		Desc:       "create a sample as an admin",
+		AuthSchema: NewBearerAuthSchema(),
+		Frontends:  []string{"manager"},
+		Errors:     []ErrorResponse{{Status: http.StatusConflict, Desc: "already | exists"}},
+	}, CreateSampleInput{}, CreateSampleOutput{})
+	filename := filepath.Join(t.TempDir(), "api.md")
+	require.NoError(t, ew.GenerateMarkdown(filename, MarkdownGeneratorConfig{
+		Title: "Sample API",
+		TagsByPrefix: []struct {
+			Prefix string
+			Tag    string
+		}{{Prefix: "/admin", Tag: "admin"}},
+	}))
+	bs, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	actual := string(bs)
+
+	assert.True(t, strings.HasPrefix(actual, "# Sample API\n"))
+	for _, expected := range []string{
+		"- [samples](#samples)\n  - [getSamplesWithQuery](#getsampleswithquery)\n",
+		"- [admin](#admin)\n  - [adminCreateSample](#admincreatesample)\n",
+		"\n## samples\n\n### getSamplesWithQuery\n\n`GET /samples/:id?yearMonth=2021-01`\n\nGET samples\n",
+		"\n## admin\n\n### adminCreateSample\n\n`POST /admin/samples`\n",
+		"| Auth | Bearer (`Authorization`) |\n| Versions | all |\n| Frontends | manager |\n| Status | 200 |\n",
+		"#### Request\n\nType: [CreateSampleInput](#createsampleinput)\n\n" +
+			"| Field | Type | Required | Description |\n| --- | --- | --- | --- |\n" +
+			"| `name` | string | yes |  |\n| `created_at` | integer | yes |  |\n",
+		"| `samples` | [SampleModel](#samplemodel)[] | yes |  |\n",
+		"| 409 |  | already \\| exists |\n",
+		"# v1 dev\ncurl -X POST 'https://dev.hoge.com/admin/samples' \\\n" +
+			"  -H 'Authorization: Bearer <token>' \\\n" +
+			"  -H 'Content-Type: application/json' \\\n" +
+			"  -d '{\"created_at\":0,\"name\":\"string\"}'\n",
+		"# v2 prod\ncurl 'https://v2.hoge.com/samples'\n",
+		"\n## Types\n\n### CreateSampleInput\n",
+	} {
+		assert.Contains(t, actual, expected)
+	}
+	// createSampleはv2にのみ含まれる
+	assert.NotContains(t, actual, "# v1 dev\ncurl -X POST 'https://dev.hoge.com/samples/:id'")
+	// 204のレスポンスはbodyをもたない
+	patch := actual[strings.Index(actual, "### patchSample"):]
+	assert.NotContains(t, patch[:strings.Index(patch, "#### Examples")], "#### Response")
+}
+
+// sampleStatus is only referenced from the query parameter of StatusSamplesInput.
+type sampleStatus string
+
+func (sampleStatus) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "string", Enum: []any{"open", "closed"}}
+}
+
+type StatusSamplesInput struct {
+	Status sampleStatus `query:"status"`
+}
+
+func TestDiff(t *testing.T) {
+	ew := newRoute(echo.New())
+	ew.AddFrontends("guest")
+	from, err := ew.Document()
+	require.NoError(t, err)
+	assert.Empty(t, Diff(from, from))
+
+	to, err := ew.Document()
+	require.NoError(t, err)
+	for i := range to.Versions {
+		v := &to.Versions[i]
+		for j := range v.APIs {
+			api := &v.APIs[j]
+			switch api.Name {
+			case "getAllSamples":
+				api.Name = "listSamples"
+			case "getSamplesWithQuery":
+				api.Path = "samples/:sampleId?yearMonth=2021-01"
+			case "createSample":
+				api.AuthSchema = NewBearerAuthSchema()
+			case "patchSample":
+				api.Deprecated = true
+			}
+		}
+		if v.Key == "v2" {
+			v.APIs = append(v.APIs, DocumentAPI{Name: "deleteSample", Path: "samples/:id", Method: http.MethodDelete, Status: http.StatusNoContent})
+		}
+	}
+	sample := to.Defs["SampleModel"]
+	sample.Properties.Delete("created_at")
+	sample.Properties.Set("memo", &jsonschema.Schema{Type: "string"})
+	input := to.Defs["CreateSampleInput"]
+	input.Properties.Set("owner", &jsonschema.Schema{Type: "string"})
+	input.Required = append(input.Required, "owner")
+	output := to.Defs["CreateSampleOutput"]
+	output.Properties.Set("id", &jsonschema.Schema{Type: "integer"})
+
+	var actual []string
+	for _, c := range Diff(from, to) {
+		if c.Key == "v1" || c.Key == "v2" {
+			actual = append(actual, c.String())
+		}
+	}
+	assert.Equal(t, []string{
+		"BREAKING [v1] getSamplesWithQuery: path changed from samples/:id to samples/:sampleId",
+		"BREAKING [v1] getAllSamples: renamed to listSamples",
+		"BREAKING [v1] SampleModel.created_at: field removed",
+		"[v1] SampleModel.memo: optional field added",
+		"BREAKING [v2] getSamplesWithQuery: path changed from samples/:id to samples/:sampleId",
+		"BREAKING [v2] createSample: auth changed from none to Bearer (Authorization)",
+		"BREAKING [v2] getAllSamples: renamed to listSamples",
+		"[v2] patchSample: deprecated",
+		"[v2] deleteSample: DELETE samples/:id added",
+		"BREAKING [v2] CreateSampleInput.owner: required field added",
+		"BREAKING [v2] CreateSampleOutput.id: type changed from string to integer",
+		"BREAKING [v2] SampleModel.created_at: field removed",
+		"[v2] SampleModel.memo: optional field added",
+	}, actual)
+
+	// レスポンスにのみ使われる型に必須のフィールドを追加しても、破壊的な変更ではない
+	output.Properties.Set("name", &jsonschema.Schema{Type: "string"})
+	output.Required = append(output.Required, "name")
+	for _, c := range Diff(from, to) {
+		if c.Def == "CreateSampleOutput" && c.Field == "name" {
+			assert.False(t, c.Breaking)
+			assert.Equal(t, ChangeFieldAdded, c.Kind)
+		}
+	}
+
+	// 両方にあるパラメータの型・enum・必須の変更
+	paramsEw := NewEchoWrapper(echo.New())
+	paramsEw.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+	paramsEw.POSTTyped("/samples/:id/search", NewSampleHandler().GetWithQuery, Desc{Name: "searchSamples"}, SearchSamplesInput{}, GetAllSamplesOutput{})
+	paramsEw.POSTTyped("/samples/status", NewSampleHandler().GetWithQuery, Desc{Name: "filterSamples"}, StatusSamplesInput{}, GetAllSamplesOutput{})
+	paramsFrom, err := paramsEw.Document()
+	require.NoError(t, err)
+	paramsTo, err := paramsEw.Document()
+	require.NoError(t, err)
+	params := paramsTo.Versions[0].APIs[0].Params
+	params.Query.Properties.Set("page", &jsonschema.Schema{Type: "string"})
+	sortParam, _ := params.Query.Properties.Get("sort")
+	sortParam.Enum = []any{"asc"}
+	params.Query.Required = append(params.Query.Required, "tags")
+	params.Header.Required = append(params.Header.Required, "Accept-Language")
+	// パラメータからのみ参照される$defsの変更
+	paramsTo.Defs["sampleStatus"].Enum = []any{"open"}
+	var paramChanges []string
+	for _, c := range Diff(paramsFrom, paramsTo) {
+		paramChanges = append(paramChanges, c.String())
+	}
+	assert.Equal(t, []string{
+		"BREAKING [v1] searchSamples: query parameter page: type changed from integer to string",
+		`BREAKING [v1] searchSamples: query parameter sort: enum values removed "desc"`,
+		"BREAKING [v1] searchSamples: query parameter tags became required",
+		"BREAKING [v1] searchSamples: header parameter Accept-Language became required",
+		`BREAKING [v1] sampleStatus: enum values removed "closed"`,
+	}, paramChanges)
+
+	to.Versions = to.Versions[:2]
+	changes := Diff(from, to)
+	assert.Equal(t, Change{Key: "guest-v2", Kind: ChangeVersionRemoved, Breaking: true, Message: "version removed"}, changes[len(changes)-1])
+	breaking := BreakingChanges(changes)
+	assert.Less(t, len(breaking), len(changes))
+	for _, c := range breaking {
+		assert.True(t, c.Breaking)
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	dir := t.TempDir()
+	ew := newRoute(echo.New())
+	ew.AddFrontends("guest")
+	from, err := ew.Document()
+	require.NoError(t, err)
+	require.NoError(t, ew.GenerateOpenApi(filepath.Join(dir, "from.yaml"), OpenApiGeneratorConfig{Version: "v2"}))
+	assert.Equal(t, "# Changelog\n\nNo changes.\n", string(Changelog(from, from, ChangelogConfig{})))
+
+	ew.GETTyped("/samples/latest", func(c *echo.Context) error { return nil }, Desc{
+		Name:      "getLatestSample",
+		Frontends: []string{"guest"},
+	}, SampleModel{})
+	to, err := ew.Document()
+	require.NoError(t, err)
+	require.NoError(t, ew.GenerateOpenApi(filepath.Join(dir, "to.yaml"), OpenApiGeneratorConfig{Version: "v2"}))
+	for i := range to.Versions {
+		v := &to.Versions[i]
+		v.APIs = slices.DeleteFunc(v.APIs, func(api DocumentAPI) bool { return api.Name == "getAllSamples" })
+		for j := range v.APIs {
+			if v.APIs[j].Name == "patchSample" {
+				v.APIs[j].Deprecated = true
+			}
+		}
+	}
+	to.Defs["SampleModel"].Properties.Set("memo", &jsonschema.Schema{Type: "string"})
+
+	filename := filepath.Join(dir, "CHANGELOG.md")
+	require.NoError(t, GenerateChangelog(filename, from, to, ChangelogConfig{Title: "v1.2.0", Keys: []string{"guest-v2"}}))
+	bs, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, `# v1.2.0
+
+1 breaking change.
+
+## guest-v2
+
+### Added
+
+- `+"`getLatestSample`"+`: GET samples/latest added
+- `+"`SampleModel.memo`"+`: optional field added
+
+### Deprecated
+
+- `+"`patchSample`"+`: deprecated
+
+### Removed
+
+- **Breaking** `+"`getAllSamples`"+`: GET samples removed
+`, string(bs))
+
+	// OpenAPIどうしの比較
+	from, err = LoadOpenApi(filepath.Join(dir, "from.yaml"))
+	require.NoError(t, err)
+	to, err = LoadOpenApi(filepath.Join(dir, "to.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Changelog\n\n## v2\n\n### Added\n\n- `getLatestSample`: GET samples/latest added\n",
+		string(Changelog(from, to, ChangelogConfig{})))
+
+	// OpenAPIどうしの比較でも、認証とパラメータの変更が検出される
+	sampleHandler := NewSampleHandler()
+	for _, c := range []struct {
+		filename string
+		desc     Desc
+	}{
+		{"from-auth.yaml", Desc{Name: "getSample"}},
+		{"to-auth.yaml", Desc{Name: "getSample", Query: "expand=owner", AuthSchema: NewBearerAuthSchema()}},
+	} {
+		ew := NewEchoWrapper(echo.New())
+		ew.AddEnv(Env{Version: "v1", Domain: Domain{Local: "http://localhost:8000"}})
+		ew.GETTyped("/samples/:id", sampleHandler.GetWithQuery, c.desc, goclient.Sample{})
+		require.NoError(t, ew.GenerateOpenApi(filepath.Join(dir, c.filename), OpenApiGeneratorConfig{}))
+	}
+	from, err = LoadOpenApi(filepath.Join(dir, "from-auth.yaml"))
+	require.NoError(t, err)
+	to, err = LoadOpenApi(filepath.Join(dir, "to-auth.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `# Changelog
+
+2 breaking changes.
+
+## v1
+
+### Added
+
+- **Breaking** `+"`getSample`"+`: required query parameter expand added
+
+### Modified
+
+- **Breaking** `+"`getSample`"+`: auth changed from none to Bearer (Authorization)
+`, string(Changelog(from, to, ChangelogConfig{})))
+}
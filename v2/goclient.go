@@ -0,0 +1,569 @@
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// GoClientGeneratorConfig は、EchoWrapper.GenerateGoClientの設定
+type GoClientGeneratorConfig struct {
+	// 生成するパッケージ名 e.g. "sampleclient"
+	Package string
+	// 対象とするバージョン e.g. "v1"
+	// 登録されたEnvが1つの場合は省略できる
+	Version string
+	// 対象とするフロントエンド e.g. "guest"
+	// 指定がない場合、フロントエンドでは絞り込まない
+	Frontend string
+}
+
+// goClientRuntime は、生成されるclientが共通して使う型と関数
+const goClientRuntime = `
+// Client は、APIを呼び出すclient
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	token      func(ctx context.Context) (string, error)
+}
+
+// Option は、Clientの設定
+type Option func(*Client)
+
+// WithBaseURL は、stageに対応するURLの代わりにbaseURLにリクエストする
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient は、http.DefaultClientの代わりにhttpClientを使う
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithToken は、AuthSchemaが指定されたAPIの認証ヘッダに使うtokenを返す関数を設定する
+func WithToken(token func(ctx context.Context) (string, error)) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// NewClient は、stageに対応するURLにリクエストするClientを返す
+func NewClient(stage endpoints.Stage, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    domain().URL(stage),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error は、2xx以外のレスポンスを表す
+type Error struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+type authScheme struct {
+	typ    string
+	header string
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, auth authScheme, body, out any) error {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth.header != "" && c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return err
+		}
+		if auth.typ == "Bearer" {
+			token = "Bearer " + token
+		}
+		req.Header.Set(auth.header, token)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &Error{StatusCode: res.StatusCode, Body: b}
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+// addParam は、vをnameのパラメータとして追加する
+// nilのポインタ・スライスは追加しない. requiredでない場合は、ポインタでないゼロ値も追加しない
+func addParam(dst interface{ Add(key, value string) }, name string, v any, required bool) {
+	rv := reflect.ValueOf(v)
+	pointer := false
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return
+		}
+		rv = rv.Elem()
+		pointer = true
+	}
+	if !rv.IsValid() || (rv.Kind() == reflect.Slice && rv.IsNil()) || (!required && !pointer && rv.IsZero()) {
+		return
+	}
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		for i := 0; i < rv.Len(); i++ {
+			dst.Add(name, fmt.Sprint(rv.Index(i).Interface()))
+		}
+		return
+	}
+	dst.Add(name, fmt.Sprint(rv.Interface()))
+}
+
+// jsonBody は、vをJSONにしたオブジェクトから、パラメータとして送るkeyを除いたものを返す
+func jsonBody(v any, keys ...string) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil || m == nil {
+		return v, nil
+	}
+	for _, k := range keys {
+		delete(m, k)
+	}
+	return m, nil
+}
+`
+
+// goClientStdImports は、goClientRuntimeが使う標準パッケージ
+func goClientStdImports() []string {
+	return []string{"bytes", "context", "encoding/json", "fmt", "io", "net/http", "net/url", "reflect"}
+}
+
+// generateGoClient は、configのバージョン・フロントエンドに含まれるAPIを呼び出すGoのclientを生成する
+// リクエスト・レスポンスには登録された型をそのまま使う
+func (e *endpoints) generateGoClient(config GoClientGeneratorConfig) ([]byte, error) {
+	if config.Package == "" {
+		return nil, fmt.Errorf("package name is required")
+	}
+	env, err := e.goClientEnv(config.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := newGoImports()
+	var methods bytes.Buffer
+	names := map[string]string{}
+	for _, api := range e.filterAPI(env.Version, config.Frontend) {
+		name := goIdentifier(api.Name, true)
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("%s and %s are both generated as the method %s", other, api.Name, name)
+		}
+		names[name] = api.Name
+		if err := writeGoClientMethod(&methods, api, imports); err != nil {
+			return nil, fmt.Errorf("%s: %w", api.Name, err)
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by endpoints-go. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", config.Package)
+	b.WriteString("import (\n")
+	for _, path := range goClientStdImports() {
+		fmt.Fprintf(&b, "\t%q\n", path)
+	}
+	b.WriteString("\n")
+	for _, path := range imports.paths() {
+		fmt.Fprintf(&b, "\t%s %q\n", imports.aliases[path], path)
+	}
+	b.WriteString(")\n")
+	b.WriteString(goClientRuntime)
+
+	b.WriteString("\nfunc domain() endpoints.Domain {\n\treturn endpoints.Domain{\n")
+	fmt.Fprintf(&b, "\t\tLocal: %q,\n\t\tLocalDev: %q,\n\t\tDev: %q,\n\t\tProd: %q,\n", env.Domain.Local, env.Domain.LocalDev, env.Domain.Dev, env.Domain.Prod)
+	b.WriteString("\t}\n}\n")
+	b.Write(methods.Bytes())
+
+	return format.Source(b.Bytes())
+}
+
+func (e *endpoints) generateGoClientFile(filename string, config GoClientGeneratorConfig) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	bs, err := e.generateGoClient(config)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, bs, 0o644)
+}
+
+// goClientEnv は、versionのEnvを返す. versionが空の場合、Envが1つだけ登録されていればそれを返す
+func (e *endpoints) goClientEnv(version string) (Env, error) {
+	if version == "" {
+		if len(e.env) != 1 {
+			return Env{}, fmt.Errorf("version is required when %d envs are registered", len(e.env))
+		}
+		return e.env[0], nil
+	}
+	for _, v := range e.env {
+		if v.Version == version {
+			return v, nil
+		}
+	}
+	return Env{}, fmt.Errorf("unknown version: %s", version)
+}
+
+func writeGoClientMethod(b *bytes.Buffer, api API, imports *goImports) error {
+	path, legacyQuery, _ := strings.Cut(api.Path, "?")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var reqType, respType string
+	var err error
+	if api.Request != nil {
+		if reqType, err = imports.typeName(reflect.TypeOf(api.Request)); err != nil {
+			return err
+		}
+	}
+	hasResponse := api.Response != nil && statusHasBody(api.status())
+	if hasResponse {
+		if respType, err = imports.typeName(reflect.TypeOf(api.Response)); err != nil {
+			return err
+		}
+	}
+
+	fields := map[string][]parameterField{}
+	required := map[string][]string{}
+	if api.Request != nil {
+		for _, pf := range parameterFields(reflect.TypeOf(api.Request)) {
+			fields[pf.in] = append(fields[pf.in], pf)
+		}
+		for _, in := range []string{openapi3.ParameterInQuery, openapi3.ParameterInHeader} {
+			if s := parameterSchema(api.Request, in, nil); s != nil {
+				required[in] = s.Required
+			}
+		}
+	}
+
+	args := []string{"ctx context.Context"}
+	// 引数の名前は、キーワードや生成するコードのローカル変数・パッケージ名と重ならないようにする
+	used := map[string]bool{}
+	for _, name := range goClientLocals() {
+		used[name] = true
+	}
+	for name := range imports.used {
+		used[name] = true
+	}
+
+	// パスパラメータは、Requestのparamタグのフィールドがなければstringの引数として受け取る
+	var pathExpr []string
+	static := ""
+	for _, segment := range strings.Split(path, "/")[1:] {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok && segment != "*" {
+			static += "/" + segment
+			continue
+		}
+		if !ok {
+			name = "*"
+		}
+		pathExpr = append(pathExpr, strconv.Quote(static+"/"))
+		static = ""
+		if i := slices.IndexFunc(fields[openapi3.ParameterInPath], func(pf parameterField) bool { return pf.name == name }); i >= 0 {
+			pathExpr = append(pathExpr, fmt.Sprintf("url.PathEscape(fmt.Sprint(req.%s))", fields[openapi3.ParameterInPath][i].field.Name))
+			continue
+		}
+		if name == "*" {
+			// ワイルドカードは複数のセグメントにまたがるためエスケープしない
+			args = append(args, "wildcard string")
+			pathExpr = append(pathExpr, "wildcard")
+			continue
+		}
+		arg := goIdentifier(name, false)
+		if token.IsKeyword(arg) || used[arg] {
+			arg += "Param"
+		}
+		base := arg
+		for i := 2; used[arg]; i++ {
+			arg = fmt.Sprintf("%s%d", base, i)
+		}
+		used[arg] = true
+		args = append(args, arg+" string")
+		pathExpr = append(pathExpr, "url.PathEscape("+arg+")")
+	}
+	if static != "" || len(pathExpr) == 0 {
+		pathExpr = append(pathExpr, strconv.Quote(static))
+	}
+	if reqType != "" {
+		args = append(args, "req "+reqType)
+	}
+
+	// Desc.Queryのみで指定されたクエリパラメータは、url.Valuesの引数として受け取る
+	legacy := false
+	for _, frag := range strings.Split(legacyQuery, "&") {
+		name, _, ok := strings.Cut(frag, "=")
+		if ok && name != "" && !slices.ContainsFunc(fields[openapi3.ParameterInQuery], func(pf parameterField) bool { return pf.name == name }) {
+			legacy = true
+		}
+	}
+	if legacy {
+		args = append(args, "query url.Values")
+	}
+
+	name := goIdentifier(api.Name, true)
+	fmt.Fprintf(b, "\n// %s は %s %s を呼び出す\n", name, api.Method, strings.TrimPrefix(api.Path, "/"))
+	for _, line := range strings.Split(api.Desc, "\n") {
+		if line != "" {
+			fmt.Fprintf(b, "// %s\n", line)
+		}
+	}
+	if api.Deprecated {
+		b.WriteString("//\n// Deprecated: このAPIは非推奨です\n")
+	}
+	if hasResponse {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), respType)
+		fmt.Fprintf(b, "\tvar resp %s\n", respType)
+	} else {
+		fmt.Fprintf(b, "func (c *Client) %s(%s) error {\n", name, strings.Join(args, ", "))
+	}
+
+	b.WriteString("\tq := url.Values{}\n")
+	if legacy {
+		b.WriteString("\tfor k, vs := range query {\n\t\tq[k] = vs\n\t}\n")
+	}
+	for _, pf := range fields[openapi3.ParameterInQuery] {
+		fmt.Fprintf(b, "\taddParam(q, %q, req.%s, %t)\n", pf.name, pf.field.Name, slices.Contains(required[pf.in], pf.name))
+	}
+	b.WriteString("\theader := http.Header{}\n")
+	for _, pf := range fields[openapi3.ParameterInHeader] {
+		fmt.Fprintf(b, "\taddParam(header, %q, req.%s, %t)\n", pf.name, pf.field.Name, slices.Contains(required[pf.in], pf.name))
+	}
+
+	// パラメータとして送るフィールドは、ボディから除く
+	body := "nil"
+	errDeclared := false
+	if api.hasRequestBody() {
+		body = "req"
+		var keys []string
+		for _, in := range []string{openapi3.ParameterInPath, openapi3.ParameterInQuery, openapi3.ParameterInHeader} {
+			for _, pf := range fields[in] {
+				if key := jsonFieldName(pf.field); key != "" {
+					keys = append(keys, strconv.Quote(key))
+				}
+			}
+		}
+		if len(keys) > 0 {
+			body = "body"
+			fmt.Fprintf(b, "\tbody, err := jsonBody(req, %s)\n\tif err != nil {\n", strings.Join(keys, ", "))
+			if hasResponse {
+				b.WriteString("\t\treturn resp, err\n\t}\n")
+			} else {
+				b.WriteString("\t\treturn err\n\t}\n")
+			}
+			errDeclared = true
+		}
+	}
+	out := "nil"
+	if hasResponse {
+		out = "&resp"
+	}
+	auth := "authScheme{}"
+	if api.AuthSchema.Header != "" {
+		auth = fmt.Sprintf("authScheme{typ: %q, header: %q}", api.AuthSchema.Type, api.AuthSchema.Header)
+	}
+	call := fmt.Sprintf("c.do(ctx, %s, %s, q, header, %s, %s, %s)",
+		goHTTPMethod(api.Method), strings.Join(pathExpr, " + "), auth, body, out)
+	switch {
+	case hasResponse && errDeclared:
+		fmt.Fprintf(b, "\terr = %s\n\treturn resp, err\n}\n", call)
+	case hasResponse:
+		fmt.Fprintf(b, "\terr := %s\n\treturn resp, err\n}\n", call)
+	default:
+		fmt.Fprintf(b, "\treturn %s\n}\n", call)
+	}
+	return nil
+}
+
+// goClientLocals are the names the generated methods use besides their arguments.
+func goClientLocals() []string {
+	return []string{"c", "ctx", "req", "resp", "q", "header", "query", "body", "err", "wildcard"}
+}
+
+// goHTTPMethod returns the net/http constant for method, or a string literal for unknown methods.
+func goHTTPMethod(method string) string {
+	for _, m := range []string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	} {
+		if m == method {
+			return "http.Method" + toPascalCase(strings.ToLower(m))
+		}
+	}
+	return strconv.Quote(method)
+}
+
+// goIdentifier turns name into a Go identifier, exported if exported is true.
+func goIdentifier(name string, exported bool) string {
+	var b strings.Builder
+	for i, part := range strings.FieldsFunc(name, isNotASCIIAlphanumeric) {
+		if i > 0 || exported {
+			part = toPascalCase(part)
+		}
+		b.WriteString(part)
+	}
+	id := b.String()
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "P" + id
+	}
+	if !exported {
+		id = toCamelCase(id)
+	}
+	return id
+}
+
+// goImports assigns package aliases to the packages of the types referenced by the generated client.
+type goImports struct {
+	aliases map[string]string // import path → alias
+	used    map[string]bool
+}
+
+func newGoImports() *goImports {
+	g := &goImports{aliases: map[string]string{}, used: map[string]bool{}}
+	for _, path := range goClientStdImports() {
+		g.used[path[strings.LastIndex(path, "/")+1:]] = true
+	}
+	g.used["endpoints"] = true
+	g.aliases[reflect.TypeOf(Domain{}).PkgPath()] = "endpoints"
+	return g
+}
+
+func (g *goImports) paths() []string {
+	paths := make([]string, 0, len(g.aliases))
+	for path := range g.aliases {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (g *goImports) alias(path string) string {
+	if alias, ok := g.aliases[path]; ok {
+		return alias
+	}
+	parts := strings.Split(path, "/")
+	base := parts[len(parts)-1]
+	if isMajorVersion(base) && len(parts) > 1 {
+		base = parts[len(parts)-2]
+	}
+	base = strings.ToLower(strings.Map(func(r rune) rune {
+		if isASCIIAlphanumeric(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, base))
+	alias := base
+	for i := 2; g.used[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+	g.aliases[path] = alias
+	g.used[alias] = true
+	return alias
+}
+
+// isMajorVersion reports whether the last element of an import path is a major version suffix such as "v2".
+func isMajorVersion(elem string) bool {
+	digits, ok := strings.CutPrefix(elem, "v")
+	return ok && digits != "" && strings.Trim(digits, "0123456789") == ""
+}
+
+// typeName returns the Go type expression of t as seen from the generated package.
+func (g *goImports) typeName(t reflect.Type) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+		if t.PkgPath() == "main" {
+			return "", fmt.Errorf("type %s in package main cannot be imported", t.Name())
+		}
+		if !token.IsExported(t.Name()) || strings.Contains(t.Name(), "[") {
+			return "", fmt.Errorf("type %s.%s cannot be referenced from another package", t.PkgPath(), t.Name())
+		}
+		return g.alias(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeName(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeName(t.Elem())
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeName(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeName(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeName(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any", nil
+		}
+	default:
+	}
+	return "", fmt.Errorf("unsupported type: %s", t)
+}
@@ -0,0 +1,212 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/labstack/echo/v5"
+)
+
+// mockMaxDepth は、再帰的な型のレスポンスを合成する際の最大の深さ
+const mockMaxDepth = 8
+
+// MockConfig は、モックサーバーの設定
+type MockConfig struct {
+	// 提供するバージョン e.g. "v1"
+	// 空文字列の場合、最初に登録されたバージョン (.endpoints.jsonの最初のkey) を使う
+	Version string
+	// 提供するフロントエンド. 空文字列の場合、フロントエンドで絞り込まない
+	Frontend string
+}
+
+// MockServer は、登録されたエンドポイントのレスポンスの例を返すモックサーバーを返す
+// e.g. mock, err := ew.MockServer(endpoints.MockConfig{Version: "v1"}); mock.Start(":8080")
+func (w *EchoWrapper) MockServer(config MockConfig) (*echo.Echo, error) {
+	doc, err := w.Document()
+	if err != nil {
+		return nil, err
+	}
+	return NewMockServer(doc, config)
+}
+
+// NewMockServer は、docのエンドポイントのレスポンスの例を返すモックサーバーを返す
+// レスポンスは、Desc.Exampleが指定されている場合はその値、そうでない場合はレスポンスのスキーマから合成した値となる
+// docには、Load・ParseやLoadOpenApi・ParseOpenApiで読み込んだものを指定できる
+func NewMockServer(doc *Document, config MockConfig) (*echo.Echo, error) {
+	if len(doc.Versions) == 0 {
+		return nil, fmt.Errorf("no versions in the document")
+	}
+	version := doc.Versions[0]
+	if config.Version != "" || config.Frontend != "" {
+		v, ok := doc.Version(config.Version, config.Frontend)
+		if !ok {
+			return nil, fmt.Errorf("unknown version: %s", documentKeyName(config.Version, config.Frontend))
+		}
+		version = v
+	}
+
+	e := echo.New()
+	for _, api := range version.APIs {
+		path, _, _ := strings.Cut(api.Path, "?")
+		e.Add(api.Method, "/"+path, mockHandler(api, doc.Defs))
+	}
+	return e, nil
+}
+
+func mockHandler(api DocumentAPI, defs jsonschema.Definitions) echo.HandlerFunc {
+	status := api.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return func(c *echo.Context) error {
+		if !statusHasBody(status) || (api.Response == nil && api.Example == nil) {
+			return c.NoContent(status)
+		}
+		if api.Example != nil {
+			return c.JSON(status, api.Example)
+		}
+		return c.JSON(status, mockValue(api.Response, defs, 0))
+	}
+}
+
+// mockValue synthesizes a value matching s.
+// Declared examples, defaults, consts and enums are preferred over synthesized values.
+func mockValue(s *jsonschema.Schema, defs jsonschema.Definitions, depth int) any {
+	if s == nil || depth > mockMaxDepth {
+		return nil
+	}
+	if s.Ref != "" {
+		return mockValue(defs[strings.TrimPrefix(s.Ref, "#/$defs/")], defs, depth+1)
+	}
+	switch {
+	case len(s.Examples) > 0:
+		return s.Examples[0]
+	case s.Default != nil:
+		return s.Default
+	case s.Const != nil:
+		return s.Const
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	}
+
+	if subs := append(append([]*jsonschema.Schema{}, s.OneOf...), s.AnyOf...); len(subs) > 0 {
+		rest, _ := withoutNullSchemas(subs)
+		if len(rest) == 0 {
+			return nil
+		}
+		return mockValue(rest[0], defs, depth+1)
+	}
+	if len(s.AllOf) > 0 {
+		merged := map[string]any{}
+		if v, ok := mockBaseValue(s, defs, depth).(map[string]any); ok {
+			merged = v
+		}
+		for _, sub := range s.AllOf {
+			v, ok := mockValue(sub, defs, depth+1).(map[string]any)
+			if !ok {
+				// allOf of non-objects only adds constraints to the value
+				return mockBaseValue(s, defs, depth)
+			}
+			for k, value := range v {
+				merged[k] = value
+			}
+		}
+		return merged
+	}
+	return mockBaseValue(s, defs, depth)
+}
+
+// mockBaseValue synthesizes a value from the type keyword of s, ignoring composition keywords.
+func mockBaseValue(s *jsonschema.Schema, defs jsonschema.Definitions, depth int) any {
+	switch s.Type {
+	case "string":
+		return mockString(s)
+	case "integer", "number":
+		return mockNumber(s)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	case "array":
+		n := 1
+		if s.MinItems != nil && *s.MinItems > 1 {
+			n = int(*s.MinItems)
+		}
+		if s.MaxItems != nil && *s.MaxItems == 0 {
+			n = 0
+		}
+		items := make([]any, 0, n)
+		for range n {
+			items = append(items, mockValue(s.Items, defs, depth+1))
+		}
+		return items
+	case "object", "":
+		obj := map[string]any{}
+		if s.Properties != nil {
+			for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				obj[pair.Key] = mockValue(pair.Value, defs, depth+1)
+			}
+			return obj
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Not == nil {
+			obj["key"] = mockValue(s.AdditionalProperties, defs, depth+1)
+		}
+		if s.Type == "" && len(obj) == 0 {
+			return nil
+		}
+		return obj
+	default:
+		return nil
+	}
+}
+
+// mockStringFormats maps JSON Schema formats to example values.
+func mockStringFormats() map[string]string {
+	return map[string]string{
+		"date-time": "2024-01-01T00:00:00Z",
+		"date":      "2024-01-01",
+		"time":      "00:00:00Z",
+		"email":     "user@example.com",
+		"uuid":      "00000000-0000-0000-0000-000000000000",
+		"uri":       "https://example.com",
+		"ipv4":      "127.0.0.1",
+		"ipv6":      "::1",
+	}
+}
+
+func mockString(s *jsonschema.Schema) string {
+	if v, ok := mockStringFormats()[s.Format]; ok {
+		return v
+	}
+	v := "string"
+	if s.MinLength != nil && uint64(len(v)) < *s.MinLength {
+		v += strings.Repeat("x", int(*s.MinLength)-len(v))
+	}
+	if s.MaxLength != nil && uint64(len(v)) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+func mockNumber(s *jsonschema.Schema) json.Number {
+	switch {
+	case s.Minimum != "":
+		return s.Minimum
+	case s.ExclusiveMinimum != "":
+		if f, err := s.ExclusiveMinimum.Float64(); err == nil {
+			return json.Number(fmt.Sprint(f + 1))
+		}
+	case s.Maximum != "":
+		if f, err := s.Maximum.Float64(); err == nil && f < 0 {
+			return s.Maximum
+		}
+	case s.ExclusiveMaximum != "":
+		if f, err := s.ExclusiveMaximum.Float64(); err == nil && f <= 0 {
+			return json.Number(fmt.Sprint(f - 1))
+		}
+	}
+	return "0"
+}
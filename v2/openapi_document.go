@@ -0,0 +1,306 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
+)
+
+// LoadOpenApi は、filenameのOpenAPI (JSONまたはYAML) を読み込み、Documentに変換する
+func LoadOpenApi(filename string) (*Document, error) {
+	bs, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseOpenApi(bs)
+}
+
+// ParseOpenApi は、OpenAPI (JSONまたはYAML) を読み込み、Documentに変換する
+// OpenAPIにはフロントエンドの区別がないため、Versionsは1つだけになる
+// そのKeyとEnvは、serversのdescription ("v1 at local"など) から分かる場合に限り設定される
+// DocumentAPIのAuthSchemaはsecurityとcomponents.securitySchemesから、Paramsはparametersから設定される
+func ParseOpenApi(data []byte) (*Document, error) {
+	t, err := openapi3.NewLoader().LoadFromData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	version := DocumentVersion{}
+	for _, server := range t.Servers {
+		v, stage, ok := strings.Cut(server.Description, " at ")
+		if !ok || (version.Key != "" && version.Key != v) {
+			continue
+		}
+		version.Key = v
+		switch Stage(stage) {
+		case StageLocal:
+			version.Env.Local = server.URL
+		case StageLocalDev:
+			version.Env.LocalDev = server.URL
+		case StageDev:
+			version.Env.Dev = server.URL
+		case StageProd:
+			version.Env.Prod = server.URL
+		default:
+		}
+	}
+
+	paths := t.Paths.Map()
+	keys := make([]string, 0, len(paths))
+	for path := range paths {
+		keys = append(keys, path)
+	}
+	sort.Strings(keys)
+	for _, path := range keys {
+		item := paths[path]
+		for _, method := range []string{
+			http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		} {
+			if op := item.GetOperation(method); op != nil {
+				api := openApiDocumentAPI(path, method, op)
+				api.AuthSchema = openApiAuthSchema(t, op)
+				api.Params = openApiParams(item, op)
+				version.APIs = append(version.APIs, api)
+			}
+		}
+	}
+
+	doc := &Document{Versions: []DocumentVersion{version}, Defs: jsonschema.Definitions{}}
+	if t.Components != nil {
+		for name, s := range t.Components.Schemas {
+			doc.Defs[name] = openApiToJSONSchema(s)
+		}
+	}
+	return doc, nil
+}
+
+// openApiDocumentAPI converts an OpenAPI operation into a DocumentAPI.
+// The path is converted back into the Echo style ("/samples/{id}" → "samples/:id").
+func openApiDocumentAPI(path, method string, op *openapi3.Operation) DocumentAPI {
+	name := op.OperationID
+	if name == "" {
+		name = method + " " + path
+	}
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = ":" + strings.Trim(segment, "{}")
+		}
+	}
+
+	api := DocumentAPI{
+		Name:       name,
+		Path:       strings.Join(segments, "/"),
+		Desc:       op.Description,
+		Method:     method,
+		Tags:       op.Tags,
+		Deprecated: op.Deprecated,
+	}
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		if mt := op.RequestBody.Value.Content.Get("application/json"); mt != nil {
+			api.Request = openApiToJSONSchema(mt.Schema)
+		}
+	}
+
+	// The first 2xx response is the one the endpoint declares
+	var statuses []int
+	for code := range op.Responses.Map() {
+		if status, err := strconv.Atoi(code); err == nil && status >= 200 && status < 300 {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Ints(statuses)
+	if len(statuses) > 0 {
+		if statuses[0] != http.StatusOK {
+			api.Status = statuses[0]
+		}
+		if res := op.Responses.Status(statuses[0]); res != nil && res.Value != nil {
+			if mt := res.Value.Content.Get("application/json"); mt != nil {
+				api.Response = openApiToJSONSchema(mt.Schema)
+				api.Example = mt.Example
+			}
+		}
+	}
+	return api
+}
+
+// openApiAuthSchema returns the AuthSchema of the security requirement of op,
+// falling back to the requirement of the whole document.
+// Schemes EchoWrapper does not generate are kept by name, so that changes to them are still detected.
+func openApiAuthSchema(t *openapi3.T, op *openapi3.Operation) AuthSchema {
+	security := t.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	if len(security) == 0 || len(security[0]) == 0 {
+		return AuthSchema{}
+	}
+	names := make([]string, 0, len(security[0]))
+	for name := range security[0] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	name := names[0]
+
+	var scheme *openapi3.SecurityScheme
+	if t.Components != nil {
+		if ref := t.Components.SecuritySchemes[name]; ref != nil {
+			scheme = ref.Value
+		}
+	}
+	switch {
+	case scheme == nil:
+		return AuthSchema{Type: name}
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+		return NewBearerAuthSchema()
+	case scheme.Type == "apiKey" && scheme.In == openapi3.ParameterInHeader:
+		// securitySchemeName names the scheme "<Type>-<Header>"
+		typ, ok := strings.CutSuffix(name, "-"+scheme.Name)
+		if !ok || typ == "" {
+			typ = "ApiKey"
+		}
+		return AuthSchema{Type: typ, Header: scheme.Name}
+	default:
+		return AuthSchema{Type: name}
+	}
+}
+
+// openApiParams converts the path, query and header parameters of op into DocumentParams.
+// Parameters of the path item apply to every operation, unless op overrides them.
+func openApiParams(item *openapi3.PathItem, op *openapi3.Operation) *DocumentParams {
+	params := &DocumentParams{}
+	for _, ref := range append(append(openapi3.Parameters{}, item.Parameters...), op.Parameters...) {
+		p := ref.Value
+		if p == nil {
+			continue
+		}
+		var target **jsonschema.Schema
+		switch p.In {
+		case openapi3.ParameterInPath:
+			target = &params.Path
+		case openapi3.ParameterInQuery:
+			target = &params.Query
+		case openapi3.ParameterInHeader:
+			target = &params.Header
+		default:
+			continue
+		}
+		if *target == nil {
+			*target = &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+		}
+		s := openApiToJSONSchema(p.Schema)
+		if s == nil {
+			s = &jsonschema.Schema{}
+		}
+		(*target).Properties.Set(p.Name, s)
+		(*target).Required = slices.DeleteFunc((*target).Required, func(name string) bool { return name == p.Name })
+		if p.Required {
+			(*target).Required = append((*target).Required, p.Name)
+		}
+	}
+	if params.Path == nil && params.Query == nil && params.Header == nil {
+		return nil
+	}
+	return params
+}
+
+// openApiToJSONSchema converts an OpenAPI schema into the JSON Schema used in .endpoints.json.
+// References to components.schemas become references to $defs.
+func openApiToJSONSchema(ref *openapi3.SchemaRef) *jsonschema.Schema {
+	if ref == nil {
+		return nil
+	}
+	if name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/"); ok {
+		return &jsonschema.Schema{Ref: "#/$defs/" + name}
+	}
+	v := ref.Value
+	if v == nil {
+		return nil
+	}
+
+	s := &jsonschema.Schema{
+		Format:      v.Format,
+		Description: v.Description,
+		Enum:        v.Enum,
+		Default:     v.Default,
+		Pattern:     v.Pattern,
+		Required:    v.Required,
+		UniqueItems: v.UniqueItems,
+		Deprecated:  v.Deprecated,
+		ReadOnly:    v.ReadOnly,
+		WriteOnly:   v.WriteOnly,
+		MaxLength:   v.MaxLength,
+		MaxItems:    v.MaxItems,
+		Items:       openApiToJSONSchema(v.Items),
+	}
+	if v.Example != nil {
+		s.Examples = []any{v.Example}
+	}
+	if v.MinLength > 0 {
+		s.MinLength = &v.MinLength
+	}
+	if v.MinItems > 0 {
+		s.MinItems = &v.MinItems
+	}
+	if v.Min != nil {
+		n := json.Number(strconv.FormatFloat(*v.Min, 'f', -1, 64))
+		if v.ExclusiveMin {
+			s.ExclusiveMinimum = n
+		} else {
+			s.Minimum = n
+		}
+	}
+	if v.Max != nil {
+		n := json.Number(strconv.FormatFloat(*v.Max, 'f', -1, 64))
+		if v.ExclusiveMax {
+			s.ExclusiveMaximum = n
+		} else {
+			s.Maximum = n
+		}
+	}
+	if len(v.Properties) > 0 {
+		names := make([]string, 0, len(v.Properties))
+		for name := range v.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		s.Properties = jsonschema.NewProperties()
+		for _, name := range names {
+			s.Properties.Set(name, openApiToJSONSchema(v.Properties[name]))
+		}
+	}
+	if v.AdditionalProperties.Schema != nil {
+		s.AdditionalProperties = openApiToJSONSchema(v.AdditionalProperties.Schema)
+	}
+	for _, sub := range v.AllOf {
+		s.AllOf = append(s.AllOf, openApiToJSONSchema(sub))
+	}
+	for _, sub := range v.AnyOf {
+		s.AnyOf = append(s.AnyOf, openApiToJSONSchema(sub))
+	}
+	for _, sub := range v.OneOf {
+		s.OneOf = append(s.OneOf, openApiToJSONSchema(sub))
+	}
+
+	nullable := v.Nullable
+	if v.Type != nil {
+		for _, typ := range *v.Type {
+			if typ == openapi3.TypeNull {
+				nullable = true
+			} else if s.Type == "" {
+				s.Type = typ
+			}
+		}
+	}
+	if nullable {
+		return &jsonschema.Schema{OneOf: []*jsonschema.Schema{s, {Type: "null"}}}
+	}
+	return s
+}
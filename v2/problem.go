@@ -0,0 +1,124 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/labstack/echo/v5"
+)
+
+// MIMEApplicationProblemJSON は、RFC 7807 のエラーレスポンスのContent-Type
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// problemTypeAboutBlank は、Problem.Typeが指定されていない場合のtype (RFC 7807 4.2)
+const problemTypeAboutBlank = "about:blank"
+
+// Problem は、RFC 7807 (Problem Details for HTTP APIs) のエラーレスポンス
+// handlerが返したProblemは、ProblemHTTPErrorHandlerによって application/problem+json として返される
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// エラーカタログに登録されたエラーコード
+	Code string `json:"code,omitempty"`
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// newProblem は、ステータスコードに対応するtitleをもつProblemを返す
+func newProblem(status int, detail string) *Problem {
+	title := http.StatusText(status)
+	if detail == title {
+		detail = ""
+	}
+	return &Problem{Type: problemTypeAboutBlank, Title: title, Status: status, Detail: detail}
+}
+
+// ErrorCode は、エラーカタログに登録するエラーを表す
+// EchoWrapper.AddErrorCodesで登録すると、.endpoints.jsonとOpenAPIに出力される
+// ErrorCodeはそのままerrorとしてhandlerから返すことができる
+type ErrorCode struct {
+	// e.g. "sample_not_found"
+	Code string
+	// e.g. http.StatusNotFound
+	Status int
+	// 人間が読むためのエラーの概要
+	Title string
+	// エラーの種類を表すURI. 指定がない場合、"about:blank"とみなす
+	Type string
+}
+
+func (c ErrorCode) Error() string {
+	return c.Code
+}
+
+// Problem は、detailを指定してcに対応するProblemを返す
+func (c ErrorCode) Problem(detail string) *Problem {
+	typ := c.Type
+	if typ == "" {
+		typ = problemTypeAboutBlank
+	}
+	title := c.Title
+	if title == "" {
+		title = http.StatusText(c.Status)
+	}
+	return &Problem{Type: typ, Title: title, Status: c.Status, Detail: detail, Code: c.Code}
+}
+
+// Response は、cをDesc.Errorsに指定するためのErrorResponseを返す
+func (c ErrorCode) Response() ErrorResponse {
+	return ErrorResponse{Status: c.Status, Body: Problem{}, Desc: c.Title, Code: c.Code}
+}
+
+// problemFromError は、errをProblemに変換する
+// Problem, ErrorCode, Echoのエラーのいずれでもない場合は500として扱い、エラーの内容はレスポンスに含めない
+func problemFromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var code ErrorCode
+	if errors.As(err, &code) {
+		return code.Problem("")
+	}
+	if p, ok := echoProblem(err); ok {
+		return p
+	}
+	return newProblem(http.StatusInternalServerError, "")
+}
+
+// writeProblem は、pを application/problem+json として返す
+func writeProblem(c *echo.Context, p *Problem) error {
+	if c.Request().Method == http.MethodHead {
+		return c.NoContent(p.Status)
+	}
+	if p.Title == "" {
+		withTitle := *p
+		withTitle.Title = http.StatusText(p.Status)
+		p = &withTitle
+	}
+	bs, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return c.Blob(p.Status, MIMEApplicationProblemJSON, bs)
+}
+
+// isProblemType は、typがProblemまたはその参照型かどうかを返す
+func isProblemType(typ any) bool {
+	t := reflect.TypeOf(typ)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == reflect.TypeOf(Problem{})
+}
@@ -0,0 +1,31 @@
+package endpoints
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v5"
+)
+
+// ProblemHTTPErrorHandler は、handlerが返したエラーを RFC 7807 の application/problem+json として返す echo.HTTPErrorHandler
+// e.HTTPErrorHandler = endpoints.ProblemHTTPErrorHandler のように設定して使う
+func ProblemHTTPErrorHandler(c *echo.Context, err error) {
+	if r, _ := echo.UnwrapResponse(c.Response()); r != nil && r.Committed {
+		return
+	}
+	if err := writeProblem(c, problemFromError(err)); err != nil {
+		c.Logger().Error("failed to send problem to client", "error", err)
+	}
+}
+
+// echoProblem は、echo.HTTPErrorやecho.HTTPStatusCoderを実装したエラーをProblemに変換する
+func echoProblem(err error) (*Problem, bool) {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return newProblem(he.Code, he.Message), true
+	}
+	var sc echo.HTTPStatusCoder
+	if errors.As(err, &sc) && sc.StatusCode() != 0 {
+		return newProblem(sc.StatusCode(), ""), true
+	}
+	return nil, false
+}
@@ -0,0 +1,208 @@
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+	"github.com/labstack/echo/v5"
+)
+
+// ResponseValidatorConfig は、EchoWrapper.ResponseValidatorの設定
+type ResponseValidatorConfig struct {
+	// trueの場合、スキーマと一致しないレスポンスの代わりに500を返す
+	// falseの場合、レスポンスはそのまま返され、OnMismatchが呼ばれるのみとなる
+	Fail bool
+	// レスポンスがスキーマと一致しない場合に呼ばれる
+	// 指定がない場合、EchoのLoggerにエラーとして出力する
+	OnMismatch func(c *echo.Context, api API, err error)
+}
+
+// ResponseValidator は、型付きハンドラのレスポンスが.endpoints.jsonやOpenAPIに出力しているスキーマと
+// 一致するかを検証するmiddlewareを返す。ローカル開発やCIでの利用を想定している
+// e.Use(ew.ResponseValidator(endpoints.ResponseValidatorConfig{Fail: true})) のように登録する
+//
+// レスポンスはバッファされてから送信されるため、ストリーミングするハンドラには使用しないこと
+func (w *EchoWrapper) ResponseValidator(config ResponseValidatorConfig) echo.MiddlewareFunc {
+	onMismatch := config.OnMismatch
+	if onMismatch == nil {
+		onMismatch = logResponseMismatch
+	}
+	validators := &sync.Map{} // API.Name → *responseSchema
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c *echo.Context) error {
+			api, ok := w.endpoints.findAPI(c.Request().Method, c.Path())
+			if !ok || api.Response == nil {
+				return next(c)
+			}
+
+			rec := &bufferedResponseWriter{header: c.Response().Header()}
+			original, restore := swapResponseWriter(c, rec)
+			err := next(c)
+			restore()
+			if err != nil || rec.status == 0 {
+				rec.flushTo(original)
+				return err
+			}
+
+			mismatch := validateResponse(validators, api, rec)
+			if mismatch == nil {
+				rec.flushTo(original)
+				return nil
+			}
+
+			onMismatch(c, api, mismatch)
+			if !config.Fail {
+				rec.flushTo(original)
+				return nil
+			}
+			resetResponse(c)
+			return echo.NewHTTPError(http.StatusInternalServerError, mismatch.Error())
+		}
+	}
+}
+
+// findAPI は、登録されたpathとmethodに一致するAPIを返す
+func (e *endpoints) findAPI(method, path string) (API, bool) {
+	for _, v := range e.api {
+		p, _, _ := strings.Cut(v.Path, "?")
+		if v.Method == method && p == path {
+			return v, true
+		}
+	}
+	return API{}, false
+}
+
+type responseSchema struct {
+	schema    *jsonschema.Schema
+	validator *schemaValidator
+}
+
+// validateResponse は、recに書き込まれたレスポンスがapiのステータスコードとスキーマに一致するかを検証する
+// エラーレスポンスは検証しない
+func validateResponse(validators *sync.Map, api API, rec *bufferedResponseWriter) error {
+	if rec.status >= http.StatusBadRequest {
+		return nil
+	}
+	if rec.status != api.status() {
+		return fmt.Errorf("status %d does not match the declared status %d", rec.status, api.status())
+	}
+	if !statusHasBody(rec.status) {
+		return nil
+	}
+
+	cached, ok := validators.Load(api.Name)
+	rs, _ := cached.(*responseSchema)
+	if !ok || rs == nil {
+		schema, _ := reflectType(api.Response)
+		validator := newStrictSchemaValidator(schema.Definitions)
+		validator.nullable = nilValueSchemas(reflect.TypeOf(api.Response), schema, schema.Definitions)
+		rs = &responseSchema{schema: schema, validator: validator}
+		validators.Store(api.Name, rs)
+	}
+
+	value, err := decodeJSONValue(rec.body.Bytes())
+	if err != nil {
+		return fmt.Errorf("response body is not JSON: %w", err)
+	}
+	return rs.validator.validate(rs.schema, value, "")
+}
+
+// nilValueSchemas returns the schemas within s reflected from slices, maps and pointers of t.
+// encoding/json encodes their nil values as null, which the reflected schemas do not allow.
+// Fields with omitempty are omitted when nil, so only their elements are included.
+func nilValueSchemas(t reflect.Type, s *jsonschema.Schema, defs jsonschema.Definitions) map[*jsonschema.Schema]bool {
+	nullable := map[*jsonschema.Schema]bool{}
+	visited := map[*jsonschema.Schema]bool{}
+	var walk func(t reflect.Type, s *jsonschema.Schema, omitempty bool)
+	walk = func(t reflect.Type, s *jsonschema.Schema, omitempty bool) {
+		if t == nil || s == nil {
+			return
+		}
+		if canBeNil(t) && !omitempty {
+			nullable[s] = true
+		}
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if s.Ref != "" {
+			s = defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		}
+		if s == nil || visited[s] {
+			return
+		}
+		visited[s] = true
+
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array:
+			walk(t.Elem(), s.Items, false)
+		case reflect.Map:
+			walk(t.Elem(), s.AdditionalProperties, false)
+		case reflect.Struct:
+			if s.Properties == nil {
+				return
+			}
+			for _, f := range reflect.VisibleFields(t) {
+				if !f.IsExported() || (f.Anonymous && f.Tag.Get("json") == "") {
+					continue
+				}
+				if property, ok := s.Properties.Get(jsonFieldName(f)); ok {
+					walk(f.Type, property, strings.Contains(f.Tag.Get("json"), ",omitempty"))
+				}
+			}
+		default:
+		}
+	}
+	walk(t, s, false)
+	return nullable
+}
+
+// canBeNil reports whether encoding/json encodes the nil value of t as null.
+func canBeNil(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		return true
+	default:
+		return false
+	}
+}
+
+// bufferedResponseWriter は、ステータスコードとボディをバッファするhttp.ResponseWriter
+// ヘッダは元のレスポンスのものを共有する
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// flushTo は、バッファしたレスポンスをresに書き込む
+// echo.Responseは既に書き込み済みとして扱われているため、resにはその下のhttp.ResponseWriterを渡すこと
+func (w *bufferedResponseWriter) flushTo(res http.ResponseWriter) {
+	if w.status == 0 {
+		return
+	}
+	res.WriteHeader(w.status)
+	_, _ = res.Write(w.body.Bytes())
+}
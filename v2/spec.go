@@ -0,0 +1,113 @@
+package endpoints
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v5"
+)
+
+// ServeEndpointsJson は、登録されたエンドポイントの.endpoints.jsonを返すハンドラをpathに登録する
+// ?version=v2&frontend=guest のように指定した場合、該当するkeyのみを返す
+// e.g. ew.ServeEndpointsJson("/_endpoints.json")
+func (w *EchoWrapper) ServeEndpointsJson(path string, m ...echo.MiddlewareFunc) echo.RouteInfo {
+	return w.Echo.GET(path, w.endpoints.specHandler(echo.MIMEApplicationJSON, func(version, frontend string) ([]byte, error) {
+		return w.endpoints.generateJsonFiltered(version, frontend)
+	}), m...)
+}
+
+// ServeOpenApiJson は、登録されたエンドポイントのOpenAPI (JSON) を返すハンドラをpathに登録する
+// ?version=v2&frontend=guest のように指定した場合、config.Version・config.Frontendより優先される
+// e.g. ew.ServeOpenApiJson("/openapi.json", endpoints.OpenApiGeneratorConfig{})
+func (w *EchoWrapper) ServeOpenApiJson(path string, config OpenApiGeneratorConfig, m ...echo.MiddlewareFunc) echo.RouteInfo {
+	return w.Echo.GET(path, w.endpoints.specHandler(echo.MIMEApplicationJSON, func(version, frontend string) ([]byte, error) {
+		var b bytes.Buffer
+		err := w.endpoints.generateOpenApiJson(&b, config.withQuery(version, frontend))
+		return b.Bytes(), err
+	}), m...)
+}
+
+// ServeOpenApi は、ServeOpenApiJsonのYAML版
+// e.g. ew.ServeOpenApi("/openapi.yaml", endpoints.OpenApiGeneratorConfig{})
+func (w *EchoWrapper) ServeOpenApi(path string, config OpenApiGeneratorConfig, m ...echo.MiddlewareFunc) echo.RouteInfo {
+	return w.Echo.GET(path, w.endpoints.specHandler("application/yaml", func(version, frontend string) ([]byte, error) {
+		var b bytes.Buffer
+		err := w.endpoints.generateOpenApiYaml(&b, config.withQuery(version, frontend))
+		return b.Bytes(), err
+	}), m...)
+}
+
+// withQuery は、クエリパラメータで指定されたversionとfrontendでcを上書きしたものを返す
+func (c OpenApiGeneratorConfig) withQuery(version, frontend string) OpenApiGeneratorConfig {
+	if version != "" {
+		c.Version = version
+	}
+	if frontend != "" {
+		c.Frontend = frontend
+	}
+	return c
+}
+
+// specDocument is a rendered document served by specHandler.
+type specDocument struct {
+	body []byte
+	etag string
+}
+
+// specHandler returns a handler serving the document rendered by render for the
+// version and frontend query parameters. Documents are rendered on the first
+// request and cached, as the endpoints do not change once the server has started.
+func (e *endpoints) specHandler(contentType string, render func(version, frontend string) ([]byte, error)) echo.HandlerFunc {
+	cache := &sync.Map{} // version + "\n" + frontend → specDocument
+	return func(c *echo.Context) error {
+		version, frontend := c.QueryParam("version"), c.QueryParam("frontend")
+		if version != "" && !slices.ContainsFunc(e.env, func(env Env) bool { return env.Version == version }) {
+			return echo.NewHTTPError(http.StatusNotFound, "unknown version: "+version)
+		}
+		if frontend != "" && !slices.Contains(e.frontends, frontend) {
+			return echo.NewHTTPError(http.StatusNotFound, "unknown frontend: "+frontend)
+		}
+
+		key := version + "\n" + frontend
+		cached, ok := cache.Load(key)
+		if !ok {
+			if err := e.validate(); err != nil {
+				return err
+			}
+			body, err := render(version, frontend)
+			if err != nil {
+				return err
+			}
+			sum := sha256.Sum256(body)
+			cached, _ = cache.LoadOrStore(key, specDocument{body: body, etag: `"` + hex.EncodeToString(sum[:16]) + `"`})
+		}
+		doc, ok := cached.(specDocument)
+		if !ok {
+			return echo.NewHTTPError(http.StatusInternalServerError, "invalid cache entry")
+		}
+
+		// デプロイごとに内容が変わるため、毎回ETagで再検証させる
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		c.Response().Header().Set("ETag", doc.etag)
+		if etagMatches(c.Request().Header.Get("If-None-Match"), doc.etag) {
+			return c.NoContent(http.StatusNotModified)
+		}
+		return c.Blob(http.StatusOK, contentType, doc.body)
+	}
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, v := range strings.Split(ifNoneMatch, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == etag || v == "*" {
+			return true
+		}
+	}
+	return false
+}
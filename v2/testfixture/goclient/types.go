@@ -0,0 +1,17 @@
+package goclient
+
+// SearchInput simulates a request bound from path, query and header parameters and a JSON body
+type SearchInput struct {
+	ID     int      `param:"id"`
+	Page   int      `query:"page" jsonschema:"required"`
+	Sort   string   `query:"sort" jsonschema:"enum=asc,enum=desc"`
+	Tags   []string `query:"tags"`
+	Locale string   `header:"Accept-Language"`
+	Name   string   `json:"name"`
+}
+
+// Sample simulates a response body
+type Sample struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
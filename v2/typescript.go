@@ -0,0 +1,537 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
+)
+
+const typeScriptHeader = `// Code generated by endpoints-go. DO NOT EDIT.
+/* eslint-disable */
+`
+
+// typeScriptRuntime は、生成されるclientが共通して使う型と関数
+const typeScriptRuntime = `
+export type Stage = "local" | "localDev" | "dev" | "prod";
+
+export interface ClientOptions {
+  /** リクエスト先のURL. 指定がない場合、stageに対応するenvのURLを使う */
+  baseUrl?: string;
+  /** 指定がない場合、"local" */
+  stage?: Stage;
+  fetch?: typeof fetch;
+  /** 全てのリクエストに付与するヘッダ (認証ヘッダなど) */
+  headers?: HeadersInit | (() => HeadersInit | Promise<HeadersInit>);
+}
+
+export class EndpointsError extends Error {
+  constructor(
+    readonly status: number,
+    readonly body: unknown,
+  ) {
+    super(` + "`request failed with status ${status}`" + `);
+  }
+}
+
+const pathParam = (value: unknown): string => encodeURIComponent(String(value));
+
+const request = async <T>(
+  options: ClientOptions,
+  env: Record<Stage, string>,
+  method: string,
+  path: string,
+  query: Record<string, unknown> | undefined,
+  headerParams: Record<string, unknown> | undefined,
+  body: unknown,
+  init: RequestInit | undefined,
+): Promise<T> => {
+  const search = new URLSearchParams();
+  for (const [key, value] of Object.entries(query ?? {})) {
+    if (value === undefined || value === null) continue;
+    for (const v of Array.isArray(value) ? value : [value]) search.append(key, String(v));
+  }
+  const qs = search.toString();
+  const url = ` + "`${options.baseUrl ?? env[options.stage ?? \"local\"]}${path}${qs === \"\" ? \"\" : `?${qs}`}`" + `;
+
+  const headers = new Headers(typeof options.headers === "function" ? await options.headers() : options.headers);
+  for (const [key, value] of Object.entries(headerParams ?? {})) {
+    if (value === undefined || value === null) continue;
+    headers.set(key, Array.isArray(value) ? value.map(String).join(", ") : String(value));
+  }
+  new Headers(init?.headers).forEach((value, key) => headers.set(key, value));
+  if (body !== undefined) headers.set("Content-Type", "application/json");
+
+  const res = await (options.fetch ?? fetch)(url, {
+    ...init,
+    method,
+    headers,
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const text = await res.text();
+  let data: unknown = undefined;
+  if (text !== "") {
+    try {
+      data = JSON.parse(text);
+    } catch {
+      data = text;
+    }
+  }
+  if (!res.ok) throw new EndpointsError(res.status, data);
+  return data as T;
+};
+`
+
+// generateTypeScript は、$defsの型のTypeScriptの型定義と、
+// .endpoints.jsonのkey ("v1", "guest-v1"など) ごとの型付きfetch clientを生成する
+func (e *endpoints) generateTypeScript() ([]byte, error) {
+	merged, renames := mergeDefs(e.collectAllDefs())
+
+	var b bytes.Buffer
+	b.WriteString(typeScriptHeader)
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := checkIdentifiers(names, typeScriptIdentifier); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, k := range e.documentKeys() {
+		keys = append(keys, k.key)
+	}
+	if err := checkIdentifiers(keys, typeScriptPascalCase); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		b.WriteString("\n")
+		writeTypeScriptDef(&b, name, merged[name])
+	}
+
+	b.WriteString(typeScriptRuntime)
+
+	for _, k := range e.documentKeys() {
+		b.WriteString("\n")
+		writeTypeScriptClient(&b, k, e.filterAPI(k.env.Version, k.frontend), renames)
+	}
+	return b.Bytes(), nil
+}
+
+func (e *endpoints) generateTypeScriptFile(filename string) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	bs, err := e.generateTypeScript()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, bs, 0o644)
+}
+
+// writeTypeScriptDef writes a $defs entry as an interface, or as a type alias
+// when it is not a plain object.
+func writeTypeScriptDef(b *bytes.Buffer, name string, s *jsonschema.Schema) {
+	writeTypeScriptComment(b, "", s.Description, s.Deprecated)
+	if s.Type == "object" && s.Properties != nil && s.Properties.Len() > 0 && !hasComposition(s) {
+		fmt.Fprintf(b, "export interface %s %s\n", typeScriptIdentifier(name), typeScriptObject(s, ""))
+		return
+	}
+	fmt.Fprintf(b, "export type %s = %s;\n", typeScriptIdentifier(name), typeScriptType(s, ""))
+}
+
+// writeTypeScriptClient writes a create<Key>Client function whose methods are keyed by API.Name.
+func writeTypeScriptClient(b *bytes.Buffer, k documentKey, apis []API, renames map[string]string) {
+	prefix := typeScriptPascalCase(k.key)
+	fmt.Fprintf(b, "const %sEnv: Record<Stage, string> = {\n", toCamelCase(prefix))
+	fmt.Fprintf(b, "  local: %s,\n", typeScriptLiteral(k.env.Domain.Local))
+	fmt.Fprintf(b, "  localDev: %s,\n", typeScriptLiteral(k.env.Domain.LocalDev))
+	fmt.Fprintf(b, "  dev: %s,\n", typeScriptLiteral(k.env.Domain.Dev))
+	fmt.Fprintf(b, "  prod: %s,\n", typeScriptLiteral(k.env.Domain.Prod))
+	b.WriteString("};\n\n")
+
+	fmt.Fprintf(b, "export const create%sClient = (options: ClientOptions = {}) => ({\n", prefix)
+	for _, api := range apis {
+		writeTypeScriptMethod(b, api, toCamelCase(prefix)+"Env", renames)
+	}
+	b.WriteString("});\n")
+}
+
+func writeTypeScriptMethod(b *bytes.Buffer, api API, env string, renames map[string]string) {
+	path, legacyQuery, _ := strings.Cut(api.Path, "?")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	var args []string
+	urlPath, pathParams := typeScriptPath(path, api.Request, renames)
+	if pathParams != nil {
+		args = append(args, "path: "+typeScriptParams(pathParams))
+	}
+	body := "undefined"
+	if api.hasRequestBody() {
+		args = append(args, "body: "+typeScriptTypeOf(api.Request, true, renames))
+		body = "body"
+	}
+	query := "undefined"
+	if q := typeScriptQuery(legacyQuery, api.Request, renames); q != nil {
+		optional := ""
+		if len(q.Required) == 0 {
+			optional = "?"
+		}
+		args = append(args, "query"+optional+": "+typeScriptParams(q))
+		query = "query"
+	}
+	headers := "undefined"
+	if api.Request != nil {
+		if h := parameterSchema(api.Request, openapi3.ParameterInHeader, renames); h != nil {
+			optional := ""
+			if len(h.Required) == 0 {
+				optional = "?"
+			}
+			args = append(args, "headers"+optional+": "+typeScriptParams(h))
+			headers = "headers"
+		}
+	}
+	args = append(args, "init?: RequestInit")
+
+	response := "void"
+	if api.Response != nil && statusHasBody(api.status()) {
+		response = typeScriptTypeOf(api.Response, false, renames)
+	}
+
+	writeTypeScriptComment(b, "  ", api.Desc, api.Deprecated)
+	fmt.Fprintf(b, "  %s: (%s) =>\n", typeScriptPropertyName(api.Name), strings.Join(args, ", "))
+	fmt.Fprintf(b, "    request<%s>(options, %s, %q, %s, %s, %s, %s, init),\n", response, env, api.Method, urlPath, query, headers, body)
+}
+
+// typeScriptPath returns the template literal building path, and an object schema of its parameters
+// (typed from the param tags of req when present, string otherwise), or nil if path has none.
+func typeScriptPath(path string, req any, renames map[string]string) (string, *jsonschema.Schema) {
+	var typed *jsonschema.Schema
+	if req != nil {
+		typed = parameterSchema(req, openapi3.ParameterInPath, renames)
+	}
+
+	var params *jsonschema.Schema
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		name, ok := strings.CutPrefix(segment, ":")
+		if !ok && segment != "*" {
+			segments[i] = escapeTemplateLiteral(segment)
+			continue
+		}
+		if !ok {
+			name = "*"
+		}
+		if params == nil {
+			params = &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+		}
+		property := &jsonschema.Schema{Type: "string"}
+		if typed != nil {
+			if p, found := typed.Properties.Get(name); found {
+				property = p
+			}
+		}
+		params.Properties.Set(name, property)
+		params.Required = append(params.Required, name)
+		segments[i] = "${pathParam(path" + typeScriptAccessor(name) + ")}"
+	}
+	return "`" + strings.Join(segments, "/") + "`", params
+}
+
+// typeScriptQuery returns an object schema of the query parameters, taken from the query tags of req
+// and the legacy Desc.Query, or nil if there are none.
+func typeScriptQuery(legacyQuery string, req any, renames map[string]string) *jsonschema.Schema {
+	var query *jsonschema.Schema
+	if req != nil {
+		query = parameterSchema(req, openapi3.ParameterInQuery, renames)
+	}
+	for _, frag := range strings.Split(legacyQuery, "&") {
+		name, _, ok := strings.Cut(frag, "=")
+		if !ok || name == "" {
+			continue
+		}
+		if query == nil {
+			query = &jsonschema.Schema{Type: "object", Properties: jsonschema.NewProperties()}
+		}
+		if _, exists := query.Properties.Get(name); exists {
+			continue
+		}
+		// Desc.Query is documented as a required string in OpenAPI as well
+		query.Properties.Set(name, &jsonschema.Schema{Type: "string"})
+		query.Required = append(query.Required, name)
+	}
+	return query
+}
+
+// typeScriptTypeOf returns the TypeScript type of the Go value typ (a request body if request is true),
+// referring to renamed $defs.
+func typeScriptTypeOf(typ any, request bool, renames map[string]string) string {
+	s, _ := reflectTypeAs(typ, request)
+	if s.Ref != "" {
+		return typeScriptType(&jsonschema.Schema{Ref: applyRenameToRef(s.Ref, renames)}, "")
+	}
+	rewriteRefs(s, renames)
+	return typeScriptType(s, "  ")
+}
+
+// typeScriptType converts a JSON Schema into a TypeScript type expression.
+// indent is the indentation of the line the type starts on.
+func typeScriptType(s *jsonschema.Schema, indent string) string {
+	if s == nil {
+		return "unknown"
+	}
+	if s.Ref != "" {
+		return typeScriptIdentifier(strings.TrimPrefix(s.Ref, "#/$defs/"))
+	}
+	if s.Const != nil {
+		return typeScriptLiteral(s.Const)
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, typeScriptLiteral(v))
+		}
+		return strings.Join(values, " | ")
+	}
+
+	var parts []string
+	if t := typeScriptBaseType(s, indent); t != "unknown" {
+		parts = append(parts, t)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		var types []string
+		for _, sub := range append(slices.Clone(s.OneOf), s.AnyOf...) {
+			types = append(types, typeScriptOperand(typeScriptType(sub, indent)))
+		}
+		parts = append(parts, strings.Join(types, " | "))
+	}
+	for _, sub := range s.AllOf {
+		// allOf entries holding only constraints add nothing to the type
+		if t := typeScriptType(sub, indent); t != "unknown" {
+			parts = append(parts, t)
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return "unknown"
+	case 1:
+		return parts[0]
+	}
+	for i, p := range parts {
+		parts[i] = typeScriptOperand(p)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// typeScriptBaseType converts the type keyword of s, ignoring composition keywords.
+func typeScriptBaseType(s *jsonschema.Schema, indent string) string {
+	switch s.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		return typeScriptOperand(typeScriptType(s.Items, indent)) + "[]"
+	case "object", "":
+		if s.Properties != nil && s.Properties.Len() > 0 {
+			return typeScriptObject(s, indent)
+		}
+		if s.Type == "" {
+			return "unknown"
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Not == nil {
+			return "Record<string, " + typeScriptType(s.AdditionalProperties, indent) + ">"
+		}
+		return "Record<string, unknown>"
+	default:
+		return "unknown"
+	}
+}
+
+// typeScriptObject renders the properties of s as an object type literal.
+func typeScriptObject(s *jsonschema.Schema, indent string) string {
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		writeTypeScriptComment(&b, indent+"  ", pair.Value.Description, pair.Value.Deprecated)
+		optional := "?"
+		if slices.Contains(s.Required, pair.Key) {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, typeScriptPropertyName(pair.Key), optional, typeScriptType(pair.Value, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// typeScriptParams renders a parameter object schema on a single line.
+func typeScriptParams(s *jsonschema.Schema) string {
+	var fields []string
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		optional := "?"
+		if slices.Contains(s.Required, pair.Key) {
+			optional = ""
+		}
+		fields = append(fields, typeScriptPropertyName(pair.Key)+optional+": "+typeScriptType(pair.Value, ""))
+	}
+	return "{ " + strings.Join(fields, "; ") + " }"
+}
+
+// typeScriptOperand parenthesizes unions and intersections used as operands of another type.
+func typeScriptOperand(t string) string {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(t); i++ {
+		switch c := t[i]; {
+		case quoted:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				quoted = false
+			}
+		case c == '"':
+			quoted = true
+		case c == '{' || c == '(' || c == '[' || c == '<':
+			depth++
+		case c == '}' || c == ')' || c == ']' || c == '>':
+			depth--
+		case depth == 0 && (c == '|' || c == '&'):
+			return "(" + t + ")"
+		}
+	}
+	return t
+}
+
+func writeTypeScriptComment(b *bytes.Buffer, indent, description string, deprecated bool) {
+	var lines []string
+	if description != "" {
+		lines = strings.Split(strings.ReplaceAll(description, "*/", "*\\/"), "\n")
+	}
+	if deprecated {
+		lines = append(lines, "@deprecated")
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, lines[0])
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s * %s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+func hasComposition(s *jsonschema.Schema) bool {
+	return len(s.AllOf) > 0 || len(s.AnyOf) > 0 || len(s.OneOf) > 0
+}
+
+func isTypeScriptIdentifier(name string) bool {
+	if name == "" || isASCIIDigit(rune(name[0])) {
+		return false
+	}
+	for _, r := range name {
+		if !isTypeScriptIdentifierRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// typeScriptIdentifier turns a $defs key into a valid identifier
+// (generic instantiations such as "Page[pkg.Item]" contain brackets and dots).
+func typeScriptIdentifier(name string) string {
+	return strings.Map(func(r rune) rune {
+		if isTypeScriptIdentifierRune(r) {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// checkIdentifiers fails when identifier maps distinct names (e.g. "a-b" and "a_b") to the same identifier.
+func checkIdentifiers(names []string, identifier func(string) string) error {
+	seen := map[string]string{}
+	for _, name := range names {
+		id := identifier(name)
+		if other, ok := seen[id]; ok && other != name {
+			return fmt.Errorf("%q and %q are both emitted as the identifier %s", other, name, id)
+		}
+		seen[id] = name
+	}
+	return nil
+}
+
+func isTypeScriptIdentifierRune(r rune) bool {
+	return isASCIIAlphanumeric(r) || r == '_' || r == '$'
+}
+
+func isASCIIDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+func isASCIIAlphanumeric(r rune) bool {
+	return 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || isASCIIDigit(r)
+}
+
+func typeScriptPropertyName(name string) string {
+	if isTypeScriptIdentifier(name) {
+		return name
+	}
+	return typeScriptLiteral(name)
+}
+
+func typeScriptAccessor(name string) string {
+	if isTypeScriptIdentifier(name) {
+		return "." + name
+	}
+	return "[" + typeScriptLiteral(name) + "]"
+}
+
+func typeScriptLiteral(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "unknown"
+	}
+	return string(bs)
+}
+
+// typeScriptPascalCase turns a .endpoints.json key such as "guest-v1" into "GuestV1".
+func typeScriptPascalCase(key string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(key, isNotASCIIAlphanumeric) {
+		b.WriteString(toPascalCase(part))
+	}
+	return b.String()
+}
+
+func isNotASCIIAlphanumeric(r rune) bool {
+	return !isASCIIAlphanumeric(r)
+}
+
+func toCamelCase(s string) string {
+	if s == "" {
+		return ""
+	}
+	return strings.ToLower(s[:1]) + s[1:]
+}
+
+func escapeTemplateLiteral(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "`", "\\`", "${", "\\${").Replace(s)
+}
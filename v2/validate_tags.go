@@ -0,0 +1,261 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// applyValidateTags translates the go-playground/validator `validate` tags on the fields of t
+// into JSON Schema keywords on the properties of s.
+// Properties are looked up by nameTag: "json" for bodies, or the parameter tag for parameter schemas.
+func applyValidateTags(s *jsonschema.Schema, t reflect.Type, nameTag string) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s == nil || s.Properties == nil || t == nil || t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get(nameTag) == "" {
+			applyValidateTags(s, f.Type, nameTag)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("validate")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		name := fieldNameByTag(f, nameTag)
+		if name == "" {
+			continue
+		}
+		property, ok := s.Properties.Get(name)
+		if !ok {
+			continue
+		}
+		if applyValidateRules(property, f.Type, tag) && !slices.Contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// fieldNameByTag returns the property name of f under nameTag, or "" if f has none.
+func fieldNameByTag(f reflect.StructField, nameTag string) string {
+	if nameTag == "json" {
+		return jsonFieldName(f)
+	}
+	return strings.Split(f.Tag.Get(nameTag), ",")[0]
+}
+
+// applyValidateRules applies the comma separated rules of a validate tag to property,
+// whose Go type is t. Rules after "dive" apply to the items of a slice.
+// Reports whether the field is required.
+func applyValidateRules(property *jsonschema.Schema, t reflect.Type, tag string) bool {
+	// The rules of a pointer apply to the value it points to, once it is not nil
+	pointer := t.Kind() == reflect.Ptr
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	required, omitempty := false, false
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch {
+		case name == "required":
+			required = true
+		case name == "omitempty":
+			omitempty = true
+		case name == "dive":
+			if property.Items != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				applyValidateRules(property.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		case strings.Contains(rule, "|"):
+			// Alternatives cannot be expressed without anyOf; leave them undocumented
+		case property.Ref != "":
+		case omitempty && !pointer && !zeroSatisfiesRule(t, name, param):
+			// The empty value skips the rules after omitempty, so rules it would fail are left off
+		default:
+			applyValidateRule(property, t.Kind(), name, param)
+		}
+	}
+	// required rejects the empty string and slice, while the JSON Schema required only needs the key
+	if required && !pointer && property.Ref == "" {
+		one := uint64(1)
+		switch t.Kind() {
+		case reflect.String:
+			if property.MinLength == nil || *property.MinLength == 0 {
+				property.MinLength = &one
+			}
+		case reflect.Slice:
+			if property.MinItems == nil || *property.MinItems == 0 {
+				property.MinItems = &one
+			}
+		default:
+		}
+	}
+	return required
+}
+
+// zeroSatisfiesRule reports whether the zero value of t satisfies the schema of a single validate rule.
+func zeroSatisfiesRule(t reflect.Type, name, param string) bool {
+	s := &jsonschema.Schema{}
+	applyValidateRule(s, t.Kind(), name, param)
+	zero, err := toJSONValue(reflect.Zero(t).Interface())
+	if err != nil {
+		return true
+	}
+	return newSchemaValidator(nil).validate(s, zero, "") == nil
+}
+
+// applyValidateRule applies a single validate rule to s.
+// The meaning of the size rules depends on kind, as in go-playground/validator.
+func applyValidateRule(s *jsonschema.Schema, kind reflect.Kind, name, param string) {
+	switch name {
+	case "min", "gte":
+		setLowerBound(s, kind, param, false)
+	case "max", "lte":
+		setUpperBound(s, kind, param, false)
+	case "gt":
+		setLowerBound(s, kind, param, true)
+	case "lt":
+		setUpperBound(s, kind, param, true)
+	case "len":
+		setLowerBound(s, kind, param, false)
+		setUpperBound(s, kind, param, false)
+	case "oneof":
+		s.Enum = nil
+		for _, v := range strings.Fields(param) {
+			s.Enum = append(s.Enum, validateEnumValue(kind, strings.Trim(v, "'")))
+		}
+	case "unique":
+		if kind == reflect.Slice || kind == reflect.Array {
+			s.UniqueItems = true
+		}
+	case "alpha":
+		setPattern(s, "^[a-zA-Z]+$")
+	case "alphanum":
+		setPattern(s, "^[a-zA-Z0-9]+$")
+	case "numeric":
+		setPattern(s, `^[-+]?[0-9]+(?:\.[0-9]+)?$`)
+	default:
+		if format, ok := validateFormats()[name]; ok && s.Format == "" {
+			s.Format = format
+		}
+	}
+}
+
+// validateFormats maps validate rules to the JSON Schema formats they correspond to.
+func validateFormats() map[string]string {
+	return map[string]string{
+		"email":        "email",
+		"url":          "uri",
+		"uri":          "uri",
+		"uuid":         "uuid",
+		"uuid4":        "uuid",
+		"uuid_rfc4122": "uuid",
+		"ipv4":         "ipv4",
+		"ipv6":         "ipv6",
+		"hostname":     "hostname",
+	}
+}
+
+func setLowerBound(s *jsonschema.Schema, kind reflect.Kind, param string, exclusive bool) {
+	if isNumberKind(kind) {
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return
+		}
+		if exclusive {
+			s.ExclusiveMinimum = json.Number(param)
+		} else {
+			s.Minimum = json.Number(param)
+		}
+		return
+	}
+	n, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return
+	}
+	if exclusive {
+		n++
+	}
+	switch kind {
+	case reflect.String:
+		s.MinLength = &n
+	case reflect.Slice, reflect.Array:
+		s.MinItems = &n
+	case reflect.Map:
+		s.MinProperties = &n
+	default:
+	}
+}
+
+func setUpperBound(s *jsonschema.Schema, kind reflect.Kind, param string, exclusive bool) {
+	if isNumberKind(kind) {
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return
+		}
+		if exclusive {
+			s.ExclusiveMaximum = json.Number(param)
+		} else {
+			s.Maximum = json.Number(param)
+		}
+		return
+	}
+	n, err := strconv.ParseUint(param, 10, 64)
+	if err != nil || (exclusive && n == 0) {
+		return
+	}
+	if exclusive {
+		n--
+	}
+	switch kind {
+	case reflect.String:
+		s.MaxLength = &n
+	case reflect.Slice, reflect.Array:
+		s.MaxItems = &n
+	case reflect.Map:
+		s.MaxProperties = &n
+	default:
+	}
+}
+
+func setPattern(s *jsonschema.Schema, pattern string) {
+	if s.Pattern == "" {
+		s.Pattern = pattern
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// validateEnumValue converts a oneof value to the JSON type of kind.
+func validateEnumValue(kind reflect.Kind, value string) any {
+	if isNumberKind(kind) {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
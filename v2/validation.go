@@ -0,0 +1,676 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/jsonschema"
+	"github.com/labstack/echo/v5"
+)
+
+// requestValidator は、型付きハンドラのリクエストを、.endpoints.jsonやOpenAPIに出力されるものと
+// 同じJSON Schemaで検証する。EchoにValidatorが登録されていない場合に使われる
+type requestValidator struct {
+	typ reflect.Type
+	// リクエストボディのスキーマ. ボディをもたない型の場合はnil
+	body *jsonschema.Schema
+	// パラメータの位置 (path, query, header) ごとのスキーマ
+	parameters map[string]*jsonschema.Schema
+	schemas    *schemaValidator
+}
+
+func newRequestValidator[Req any]() *requestValidator {
+	var req Req
+	schema, _ := reflectRequestType(req)
+	v := &requestValidator{
+		typ:        reflect.TypeOf(req),
+		parameters: map[string]*jsonschema.Schema{},
+		schemas:    newSchemaValidator(schema.Definitions),
+	}
+	if hasBodyFields(req) {
+		v.body = schema
+	}
+	for _, loc := range parameterLocations() {
+		if s := parameterSchema(req, loc.in, nil); s != nil {
+			v.parameters[loc.in] = s
+		}
+	}
+	return v
+}
+
+// bindAndValidate は、リクエストをrにBindして検証する
+// EchoにValidatorが登録されている場合はそれを使い、登録されていない場合はvのスキーマで検証する
+func bindAndValidate(c *echo.Context, r any, v *requestValidator) error {
+	if c.Echo().Validator != nil {
+		if err := v.bind(c, r); err != nil {
+			return err
+		}
+		return c.Validate(r)
+	}
+
+	req := c.Request()
+	var body []byte
+	if req.Body != nil {
+		bs, err := io.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		body = bs
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if err := v.bind(c, r); err != nil {
+		return err
+	}
+	return v.validate(c, body)
+}
+
+// bind は、リクエストをrにBindする
+// EchoのBindはGET, DELETE, HEAD以外のクエリパラメータと、ヘッダをBindしないため、
+// OpenAPIに出力されるqueryタグ・headerタグのフィールドはここでBindする
+func (v *requestValidator) bind(c *echo.Context, r any) error {
+	if err := c.Bind(r); err != nil {
+		return err
+	}
+	if _, ok := v.parameters[openapi3.ParameterInQuery]; ok {
+		switch c.Request().Method {
+		case http.MethodGet, http.MethodDelete, http.MethodHead:
+		default:
+			if err := bindQueryParams(c, r); err != nil {
+				return err
+			}
+		}
+	}
+	if _, ok := v.parameters[openapi3.ParameterInHeader]; ok {
+		if err := bindHeaders(c, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *requestValidator) validate(c *echo.Context, body []byte) error {
+	if err := v.validateParameters(c); err != nil {
+		return err
+	}
+
+	if v.body == nil {
+		return nil
+	}
+	// GETなどボディを送らないリクエストは、ボディがある場合に限り検証する
+	method := c.Request().Method
+	hasBodyMethod := method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+	if len(bytes.TrimSpace(body)) == 0 {
+		if !hasBodyMethod {
+			return nil
+		}
+		body = []byte("{}")
+	}
+	value, err := decodeJSONValue(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := v.schemas.validate(v.body, value, ""); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+// validateParameters は、リクエストに含まれていたパス・クエリパラメータとヘッダを検証する
+// 文字列として送られた値をスキーマの型に変換するため、Bindされた値ではなくリクエストの値を検証する
+func (v *requestValidator) validateParameters(c *echo.Context) error {
+	if len(v.parameters) == 0 {
+		return nil
+	}
+
+	values := map[string]map[string]any{}
+	for _, pf := range parameterFields(v.typ) {
+		s, ok := v.parameters[pf.in]
+		if !ok {
+			continue
+		}
+		raw, ok := parameterValues(c, pf)
+		if !ok {
+			continue
+		}
+		property, _ := s.Properties.Get(pf.name)
+		if values[pf.in] == nil {
+			values[pf.in] = map[string]any{}
+		}
+		values[pf.in][pf.name] = v.schemas.parameterValue(property, raw)
+	}
+
+	for _, loc := range parameterLocations() {
+		s, ok := v.parameters[loc.in]
+		if !ok {
+			continue
+		}
+		instance := values[loc.in]
+		if instance == nil {
+			instance = map[string]any{}
+		}
+		if err := v.schemas.validate(s, instance, ""); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid %s parameter: %v", loc.in, err))
+		}
+	}
+	return nil
+}
+
+// parameterValues は、リクエストに含まれるpfの値を返す. 含まれていない場合はfalseを返す
+func parameterValues(c *echo.Context, pf parameterField) ([]string, bool) {
+	switch pf.in {
+	case openapi3.ParameterInPath:
+		return []string{c.Param(pf.name)}, true
+	case openapi3.ParameterInQuery:
+		values, ok := c.QueryParams()[pf.name]
+		return values, ok
+	case openapi3.ParameterInHeader:
+		values := c.Request().Header.Values(pf.name)
+		return values, len(values) > 0
+	default:
+		return nil, false
+	}
+}
+
+// parameterValue converts the raw values of a parameter to the JSON value described by s.
+// Values that cannot be converted are kept as strings so that the type check reports them.
+func (v *schemaValidator) parameterValue(s *jsonschema.Schema, raw []string) any {
+	s = v.resolve(s)
+	if s != nil && s.Type == "array" {
+		items := make([]any, 0, len(raw))
+		for _, r := range raw {
+			items = append(items, v.scalarParameterValue(s.Items, r))
+		}
+		return items
+	}
+	if len(raw) == 0 {
+		return ""
+	}
+	return v.scalarParameterValue(s, raw[0])
+}
+
+func (v *schemaValidator) scalarParameterValue(s *jsonschema.Schema, raw string) any {
+	s = v.resolve(s)
+	if s == nil {
+		return raw
+	}
+	switch s.Type {
+	case "integer", "number":
+		if _, ok := new(big.Float).SetString(raw); ok {
+			return json.Number(raw)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// resolve は、sが$refの場合に参照先のスキーマを返す
+func (v *schemaValidator) resolve(s *jsonschema.Schema) *jsonschema.Schema {
+	for s != nil && s.Ref != "" {
+		def, ok := v.defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+		if !ok {
+			return nil
+		}
+		s = def
+	}
+	return s
+}
+
+// decodeJSONValue は、数値をjson.Numberとしてbsをdecodeする
+func decodeJSONValue(bs []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(bs))
+	d.UseNumber()
+	var value any
+	if err := d.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// toJSONValue は、xをJSONとしてencodeしたときの値に変換する
+func toJSONValue(x any) (any, error) {
+	bs, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSONValue(bs)
+}
+
+// schemaValidationError is a violation of a schema at a JSON path.
+type schemaValidationError struct {
+	path   string
+	reason string
+}
+
+func (e *schemaValidationError) Error() string {
+	if e.path == "" {
+		return e.reason
+	}
+	return fmt.Sprintf("%s: %s", e.path, e.reason)
+}
+
+// schemaValidator validates decoded JSON values against schemas reflected by reflectType.
+// It covers the keywords the reflector emits (type, enum, const, bounds, lengths, pattern,
+// format, required, items and the composition keywords). additionalProperties is only enforced
+// by validators created with newStrictSchemaValidator, so that clients sending extra fields keep working.
+type schemaValidator struct {
+	defs     jsonschema.Definitions
+	patterns sync.Map // pattern → *regexp.Regexp
+	// strict enforces additionalProperties
+	strict bool
+	// nullable holds the schemas that also accept null, since Go encodes their nil values as null
+	nullable map[*jsonschema.Schema]bool
+}
+
+func newSchemaValidator(defs jsonschema.Definitions) *schemaValidator {
+	return &schemaValidator{defs: defs}
+}
+
+// newStrictSchemaValidator returns a schemaValidator that also enforces additionalProperties,
+// rejecting fields that are not published.
+func newStrictSchemaValidator(defs jsonschema.Definitions) *schemaValidator {
+	return &schemaValidator{defs: defs, strict: true}
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) error {
+	return &schemaValidationError{path: path, reason: fmt.Sprintf(format, args...)}
+}
+
+func (v *schemaValidator) validate(s *jsonschema.Schema, value any, path string) error {
+	if s == nil || (value == nil && v.nullable[s]) {
+		return nil
+	}
+	if s.Ref != "" {
+		return v.validate(v.resolve(s), value, path)
+	}
+	if s.Not != nil && v.validate(s.Not, value, path) == nil {
+		return v.fail(path, "must not match the schema")
+	}
+	if err := v.validateComposition(s, value, path); err != nil {
+		return err
+	}
+
+	if s.Type != "" && !jsonTypeMatches(s.Type, value) {
+		return v.fail(path, "must be %s", s.Type)
+	}
+	if len(s.Enum) > 0 && !containsJSONValue(s.Enum, value) {
+		return v.fail(path, "must be one of %v", s.Enum)
+	}
+	if s.Const != nil && !jsonValueEqual(s.Const, value) {
+		return v.fail(path, "must be %v", s.Const)
+	}
+
+	switch x := value.(type) {
+	case string:
+		return v.validateString(s, x, path)
+	case json.Number:
+		return v.validateNumber(s, x, path)
+	case []any:
+		return v.validateArray(s, x, path)
+	case map[string]any:
+		return v.validateObject(s, x, path)
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateComposition(s *jsonschema.Schema, value any, path string) error {
+	for _, sub := range s.AllOf {
+		if err := v.validate(sub, value, path); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 {
+		var firstErr error
+		for _, sub := range s.AnyOf {
+			err := v.validate(sub, value, path)
+			if err == nil {
+				firstErr = nil
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr != nil {
+			return firstErr
+		}
+	}
+	if len(s.OneOf) > 0 {
+		matched := 0
+		var firstErr error
+		for _, sub := range s.OneOf {
+			if err := v.validate(sub, value, path); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			matched++
+		}
+		switch {
+		case matched == 0:
+			return firstErr
+		case matched > 1:
+			return v.fail(path, "must match exactly one schema in oneOf")
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateString(s *jsonschema.Schema, value, path string) error {
+	length := uint64(utf8.RuneCountInString(value))
+	if s.MinLength != nil && length < *s.MinLength {
+		return v.fail(path, "must be at least %d characters", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		return v.fail(path, "must be at most %d characters", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := v.pattern(s.Pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(value) {
+			return v.fail(path, "must match the pattern %q", s.Pattern)
+		}
+	}
+	if s.Format != "" && !stringFormatMatches(s.Format, value) {
+		return v.fail(path, "must be a valid %s", s.Format)
+	}
+	return nil
+}
+
+func (v *schemaValidator) pattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns.Load(pattern); ok {
+		if compiled, ok := re.(*regexp.Regexp); ok {
+			return compiled, nil
+		}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	v.patterns.Store(pattern, re)
+	return re, nil
+}
+
+func (v *schemaValidator) validateNumber(s *jsonschema.Schema, value json.Number, path string) error {
+	n, ok := new(big.Float).SetString(value.String())
+	if !ok {
+		return v.fail(path, "must be a number")
+	}
+	compare := func(bound json.Number) (int, bool) {
+		if bound == "" {
+			return 0, false
+		}
+		b, ok := new(big.Float).SetString(bound.String())
+		if !ok {
+			return 0, false
+		}
+		return n.Cmp(b), true
+	}
+	if c, ok := compare(s.Minimum); ok && c < 0 {
+		return v.fail(path, "must be greater than or equal to %s", s.Minimum)
+	}
+	if c, ok := compare(s.Maximum); ok && c > 0 {
+		return v.fail(path, "must be less than or equal to %s", s.Maximum)
+	}
+	if c, ok := compare(s.ExclusiveMinimum); ok && c <= 0 {
+		return v.fail(path, "must be greater than %s", s.ExclusiveMinimum)
+	}
+	if c, ok := compare(s.ExclusiveMaximum); ok && c >= 0 {
+		return v.fail(path, "must be less than %s", s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != "" {
+		m, ok := new(big.Rat).SetString(s.MultipleOf.String())
+		r, ok2 := new(big.Rat).SetString(value.String())
+		if ok && ok2 && m.Sign() != 0 && !new(big.Rat).Quo(r, m).IsInt() {
+			return v.fail(path, "must be a multiple of %s", s.MultipleOf)
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateArray(s *jsonschema.Schema, value []any, path string) error {
+	length := uint64(len(value))
+	if s.MinItems != nil && length < *s.MinItems {
+		return v.fail(path, "must have at least %d items", *s.MinItems)
+	}
+	if s.MaxItems != nil && length > *s.MaxItems {
+		return v.fail(path, "must have at most %d items", *s.MaxItems)
+	}
+	if s.UniqueItems {
+		for i := range value {
+			for j := i + 1; j < len(value); j++ {
+				if jsonValueEqual(value[i], value[j]) {
+					return v.fail(path, "must not contain duplicate items")
+				}
+			}
+		}
+	}
+	for i, item := range value {
+		if err := v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *schemaValidator) validateObject(s *jsonschema.Schema, value map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := value[name]; !ok {
+			return v.fail(joinJSONPath(path, name), "is required")
+		}
+	}
+	length := uint64(len(value))
+	if s.MinProperties != nil && length < *s.MinProperties {
+		return v.fail(path, "must have at least %d properties", *s.MinProperties)
+	}
+	if s.MaxProperties != nil && length > *s.MaxProperties {
+		return v.fail(path, "must have at most %d properties", *s.MaxProperties)
+	}
+	if v.strict && s.AdditionalProperties != nil {
+		if err := v.validateAdditionalProperties(s, value, path); err != nil {
+			return err
+		}
+	}
+	if s.Properties == nil {
+		return nil
+	}
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		property, ok := value[pair.Key]
+		if !ok {
+			continue
+		}
+		if err := v.validate(pair.Value, property, joinJSONPath(path, pair.Key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateAdditionalProperties validates the properties of value not declared in s.Properties
+// against s.AdditionalProperties, in key order.
+func (v *schemaValidator) validateAdditionalProperties(s *jsonschema.Schema, value map[string]any, path string) error {
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		if s.Properties != nil {
+			if _, ok := s.Properties.Get(k); ok {
+				continue
+			}
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if isFalseSchema(s.AdditionalProperties) {
+			return v.fail(joinJSONPath(path, k), "is not allowed")
+		}
+		if err := v.validate(s.AdditionalProperties, value[k], joinJSONPath(path, k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isFalseSchema reports whether s is the boolean schema false, which the reflector emits as
+// additionalProperties of structs.
+func isFalseSchema(s *jsonschema.Schema) bool {
+	bs, err := json.Marshal(s)
+	return err == nil && string(bs) == "false"
+}
+
+func joinJSONPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func jsonTypeMatches(typ string, value any) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		f, ok := new(big.Float).SetString(n.String())
+		return ok && f.IsInt()
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	default:
+		return true
+	}
+}
+
+// jsonValueEqual は、aとbをJSONの値として比較する. 数値は値として比較される
+func jsonValueEqual(a, b any) bool {
+	na, err := toJSONValue(a)
+	if err != nil {
+		return false
+	}
+	nb, err := toJSONValue(b)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalizeJSONNumbers(na), normalizeJSONNumbers(nb))
+}
+
+func containsJSONValue(values []any, value any) bool {
+	for _, v := range values {
+		if jsonValueEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeJSONNumbers は、json.Numberを比較可能な文字列表現に揃える (1 と 1.0 を同じ値として扱う)
+func normalizeJSONNumbers(value any) any {
+	switch x := value.(type) {
+	case json.Number:
+		if f, ok := new(big.Float).SetString(x.String()); ok {
+			return f.Text('g', -1)
+		}
+		return x.String()
+	case []any:
+		normalized := make([]any, len(x))
+		for i, v := range x {
+			normalized[i] = normalizeJSONNumbers(v)
+		}
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(x))
+		for k, v := range x {
+			normalized[k] = normalizeJSONNumbers(v)
+		}
+		return normalized
+	default:
+		return value
+	}
+}
+
+// stringFormatMatches は、valueがformatを満たすかどうかを返す. 未知のformatは常に満たすものとして扱う
+func stringFormatMatches(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "time":
+		_, err := time.Parse("15:04:05Z07:00", value)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(value)
+		return err == nil && addr.Address == value
+	case "uuid":
+		return isUUID(value)
+	case "uri":
+		u, err := url.Parse(value)
+		return err == nil && u.IsAbs()
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil && !strings.Contains(value, ":")
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && strings.Contains(value, ":")
+	default:
+		return true
+	}
+}
+
+// isUUID は、valueが8-4-4-4-12桁の16進数で表されたUUIDかどうかを返す
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i, r := range value {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
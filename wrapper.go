@@ -3,6 +3,8 @@ package endpoints
 import (
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 
 	"github.com/labstack/echo/v4"
 )
@@ -21,11 +23,64 @@ type EchoWrapper struct {
 // ただし、それによりエンドポイントを生やす場合は、
 // 当該エンドポイントの情報をGroupWrapper.AddAPI()により追加すること。
 type GroupWrapper struct {
-	Group     *echo.Group
-	prefix    string
-	versions  []string
-	frontends []string
-	parent    *EchoWrapper
+	Group   *echo.Group
+	prefix  string
+	options GroupOptions
+	parent  *EchoWrapper
+}
+
+// GroupOptions は、グループを通じて登録される全てのエンドポイントのDescに適用されるデフォルト値
+//
+// Versions, Frontends, Tags はDescに指定されたものと合わせたものが設定され、
+// AuthSchema はDescに指定されていない場合に限り設定される。
+// Deprecated, Hidden はグループとDescのどちらかで指定されていれば有効になる。
+// Metadata はキーごとに合わせられ、同じキーがある場合はDescの値が優先される。
+type GroupOptions struct {
+	Versions   []string
+	Frontends  []string
+	AuthSchema AuthSchema
+	Tags       []string
+	Deprecated bool
+	Hidden     bool
+	Metadata   map[string]string
+}
+
+// merge は、oを親とし、childを子としたネストしたグループのGroupOptionsを返す
+func (o GroupOptions) merge(child GroupOptions) GroupOptions {
+	authSchema := child.AuthSchema
+	if authSchema == (AuthSchema{}) {
+		authSchema = o.AuthSchema
+	}
+	return GroupOptions{
+		Versions:   mergeStrings(o.Versions, child.Versions),
+		Frontends:  mergeStrings(o.Frontends, child.Frontends),
+		AuthSchema: authSchema,
+		Tags:       mergeStrings(o.Tags, child.Tags),
+		Deprecated: o.Deprecated || child.Deprecated,
+		Hidden:     o.Hidden || child.Hidden,
+		Metadata:   mergeMetadata(o.Metadata, child.Metadata),
+	}
+}
+
+// apply は、oをデフォルト値としてdescに適用したDescを返す
+func (o GroupOptions) apply(desc Desc) Desc {
+	merged := o.merge(GroupOptions{
+		Versions:   desc.Versions,
+		Frontends:  desc.Frontends,
+		AuthSchema: desc.AuthSchema,
+		Tags:       desc.Tags,
+		Deprecated: desc.Deprecated,
+		Hidden:     desc.Hidden,
+		Metadata:   desc.Metadata,
+	})
+	desc.Versions = merged.Versions
+	desc.Frontends = merged.Frontends
+	desc.AuthSchema = merged.AuthSchema
+	desc.Tags = merged.Tags
+	desc.Deprecated = merged.Deprecated
+	desc.Hidden = merged.Hidden
+	desc.Metadata = merged.Metadata
+	return desc
 }
 
 func NewEchoWrapper(e *echo.Echo) *EchoWrapper {
@@ -45,17 +100,18 @@ func (w *EchoWrapper) AddFrontends(frontends ...string) {
 	w.endpoints.addFrontends(frontends...)
 }
 
+// AddErrorCodes は、エラーカタログにエラーを登録する
+// 登録したエラーは.endpoints.jsonの$errorsと、OpenAPIのcomponents.responsesに出力される
+func (w *EchoWrapper) AddErrorCodes(codes ...ErrorCode) {
+	w.endpoints.addErrorCodes(codes...)
+}
+
 // AddAPI は、原則として外部から直接呼ばないこと
 // ただし、wrapされたEchoを直接使ってエンドポイントを生やす場合
 // （EchoWrapperが対応していないメソッドを使う場合など）
 // に限り、直接呼んでよい
 func (w *EchoWrapper) AddAPI(path string, desc Desc, method string) {
-	w.endpoints.addAPI(API{
-		Name:   desc.Name,
-		Path:   path + desc.query(),
-		Desc:   desc.Desc,
-		Method: method,
-	})
+	w.endpoints.addAPI(desc.api(path, method, nil, nil))
 }
 
 // AddAPITyped は、原則として外部から直接呼ばないこと
@@ -63,20 +119,37 @@ func (w *EchoWrapper) AddAPI(path string, desc Desc, method string) {
 // （EchoWrapperが対応していないメソッドを使う場合など）
 // に限り、直接呼んでよい
 func (w *EchoWrapper) AddAPITyped(path string, desc Desc, method string, req any, resp any) {
-	w.endpoints.addAPI(API{
-		Name:     desc.Name,
-		Path:     path + desc.query(),
-		Desc:     desc.Desc,
-		Method:   method,
-		Request:  req,
-		Response: resp,
-	})
+	w.endpoints.addAPI(desc.api(path, method, req, resp))
 }
 
 func (w *EchoWrapper) Generate(filename string) error {
 	return w.endpoints.generate(filename)
 }
 
+// GenerateTypeScript は、.endpoints.jsonの$defsの型のTypeScriptの型定義と、
+// バージョン・フロントエンドごとの型付きfetch client (createV1Client, createGuestV1Clientなど) をfilenameに出力する
+func (w *EchoWrapper) GenerateTypeScript(filename string) error {
+	return w.endpoints.generateTypeScriptFile(filename)
+}
+
+// GenerateZod は、.endpoints.jsonの$defsの型のZodスキーマ (SampleModelSchemaなど) と、
+// バージョン・フロントエンドごとのエンドポイントのリクエスト・レスポンスのスキーマ (endpoints) をfilenameに出力する
+func (w *EchoWrapper) GenerateZod(filename string) error {
+	return w.endpoints.generateZodFile(filename)
+}
+
+// GenerateGoClient は、config.Version・config.Frontendに含まれるAPIを呼び出すGoのclientをfilenameに出力する
+// clientのメソッドはDesc.Nameごとに生やされ、リクエスト・レスポンスには登録された型がそのまま使われる
+func (w *EchoWrapper) GenerateGoClient(filename string, config GoClientGeneratorConfig) error {
+	return w.endpoints.generateGoClientFile(filename, config)
+}
+
+// GenerateMarkdown は、登録されたエンドポイントのリファレンスをMarkdownでfilenameに出力する
+// エンドポイントはconfig.TagsByPrefix・Desc.Tags・pathの最初の要素ごとにまとめられ、stageごとのcurlの例を含む
+func (w *EchoWrapper) GenerateMarkdown(filename string, config MarkdownGeneratorConfig) error {
+	return w.endpoints.generateMarkdownFile(filename, config)
+}
+
 func (w *EchoWrapper) GenerateOpenApiJson(filename string, config OpenApiGeneratorConfig) error {
 	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
 	if err != nil {
@@ -100,6 +173,33 @@ func (w *EchoWrapper) GenerateOpenApi(filename string, config OpenApiGeneratorCo
 	return w.endpoints.generateOpenApiYaml(file, config)
 }
 
+// GenerateOpenApiPerVersion は、.endpoints.jsonのkey ("v1", "guest-v1"など) ごとに
+// そのバージョン・フロントエンドに含まれるエンドポイントのみのOpenAPIをdir/<key>.yamlに出力する
+func (w *EchoWrapper) GenerateOpenApiPerVersion(dir string, config OpenApiGeneratorConfig) error {
+	for _, k := range w.endpoints.documentKeys() {
+		c := config
+		c.Version = k.env.Version
+		c.Frontend = k.frontend
+		if err := w.GenerateOpenApi(filepath.Join(dir, k.key+".yaml"), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GenerateOpenApiJsonPerVersion は、GenerateOpenApiPerVersionのJSON版で、dir/<key>.jsonに出力する
+func (w *EchoWrapper) GenerateOpenApiJsonPerVersion(dir string, config OpenApiGeneratorConfig) error {
+	for _, k := range w.endpoints.documentKeys() {
+		c := config
+		c.Version = k.env.Version
+		c.Frontend = k.frontend
+		if err := w.GenerateOpenApiJson(filepath.Join(dir, k.key+".json"), c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (w *EchoWrapper) GET(path string, h echo.HandlerFunc, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	w.AddAPI(path, desc, "GET")
 	return w.Echo.GET(path, h, m...)
@@ -130,6 +230,13 @@ func (w *EchoWrapper) GETTyped(path string, h echo.HandlerFunc, desc Desc, resp
 	return w.Echo.GET(path, h, m...)
 }
 
+// GETTypedWithRequest は、GETTyped と異なりRequestの型を受け取る。
+// Requestはparam, queryタグによりパスパラメータとクエリパラメータとして扱われる。
+func (w *EchoWrapper) GETTypedWithRequest(path string, h echo.HandlerFunc, desc Desc, req any, resp any, m ...echo.MiddlewareFunc) *echo.Route {
+	w.AddAPITyped(path, desc, "GET", req, resp)
+	return w.Echo.GET(path, h, m...)
+}
+
 func (w *EchoWrapper) POSTTyped(path string, h echo.HandlerFunc, desc Desc, req any, resp any, m ...echo.MiddlewareFunc) *echo.Route {
 	w.AddAPITyped(path, desc, "POST", req, resp)
 	return w.Echo.POST(path, h, m...)
@@ -150,13 +257,11 @@ func (w *EchoWrapper) DELETETyped(path string, h echo.HandlerFunc, desc Desc, re
 	return w.Echo.DELETE(path, h, m...)
 }
 
-func makeHandler[Req any, Resp any](h func(ctx echo.Context, req Req) (Resp, error)) echo.HandlerFunc {
+func makeHandler[Req any, Resp any](h func(ctx echo.Context, req Req) (Resp, error), status int) echo.HandlerFunc {
+	validator := newRequestValidator[Req]()
 	return func(c echo.Context) error {
 		var r Req
-		if err := c.Bind(&r); err != nil {
-			return err
-		}
-		if err := c.Validate(&r); err != nil {
+		if err := bindAndValidate(c, &r, validator); err != nil {
 			return err
 		}
 
@@ -165,28 +270,26 @@ func makeHandler[Req any, Resp any](h func(ctx echo.Context, req Req) (Resp, err
 			return err
 		}
 
-		return c.JSON(http.StatusOK, resp)
+		return respond(c, status, resp)
 	}
 }
 
-func makeHandlerNoRequest[Resp any](h func(ctx echo.Context) (Resp, error)) echo.HandlerFunc {
+func makeHandlerNoRequest[Resp any](h func(ctx echo.Context) (Resp, error), status int) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		resp, err := h(c)
 		if err != nil {
 			return err
 		}
 
-		return c.JSON(http.StatusOK, resp)
+		return respond(c, status, resp)
 	}
 }
 
-func makeHandlerNoContent[Req any](h func(ctx echo.Context, req Req) error) echo.HandlerFunc {
+func makeHandlerNoContent[Req any](h func(ctx echo.Context, req Req) error, status int) echo.HandlerFunc {
+	validator := newRequestValidator[Req]()
 	return func(c echo.Context) error {
 		var r Req
-		if err := c.Bind(&r); err != nil {
-			return err
-		}
-		if err := c.Validate(&r); err != nil {
+		if err := bindAndValidate(c, &r, validator); err != nil {
 			return err
 		}
 
@@ -194,126 +297,149 @@ func makeHandlerNoContent[Req any](h func(ctx echo.Context, req Req) error) echo
 			return err
 		}
 
-		return c.NoContent(http.StatusNoContent)
+		return c.NoContent(status)
+	}
+}
+
+// respond は、statusとrespをJSONとして返す。204などbodyをもたないstatusの場合はbodyを返さない
+func respond(c echo.Context, status int, resp any) error {
+	if !statusHasBody(status) {
+		return c.NoContent(status)
 	}
+	return c.JSON(status, resp)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 NOTE: Go1.20時点では、メソッドがtype parameterをもてないので関数として定義されている
 */
 func EwGET[Resp any](w *EchoWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return w.GETTyped(path, makeHandlerNoRequest(h), desc, resp, m...)
+	return w.GETTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, resp, m...)
 }
 
 /*
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Requestはパスパラメータとクエリパラメータから `param` `query` タグに従ってBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
+
+Requestは.endpoints.jsonとOpenAPIにおいて、リクエストボディではなくパラメータとして出力される。
+*/
+func EwGETWithRequest[Req any, Resp any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	var req Req
+	var resp Resp
+	return w.GETTypedWithRequest(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
+}
+
+/*
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは EwPOSTNoRequest を使い、Responseを返さないケースでは EwPOSTNoContent を使うこと。
 */
 func EwPOST[Req any, Resp any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return w.POSTTyped(path, makeHandler(h), desc, req, resp, m...)
+	return w.POSTTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func EwPOSTNoRequest[Resp any](w *EchoWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return w.POSTTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return w.POSTTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func EwPOSTNoContent[Req any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return w.POSTTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return w.POSTTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは EwPUTNoRequest を使い、Responseを返さないケースでは EwPUTNoContent を使うこと。
 */
 func EwPUT[Req any, Resp any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return w.PUTTyped(path, makeHandler(h), desc, req, resp, m...)
+	return w.PUTTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func EwPUTNoRequest[Resp any](w *EchoWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return w.PUTTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return w.PUTTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func EwPUTNoContent[Req any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return w.PUTTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return w.PUTTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは EwPATCHNoRequest を使い、Responseを返さないケースでは EwPATCHNoContent を使うこと。
 */
 func EwPATCH[Req any, Resp any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return w.PATCHTyped(path, makeHandler(h), desc, req, resp, m...)
+	return w.PATCHTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func EwPATCHNoRequest[Resp any](w *EchoWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return w.PATCHTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return w.PATCHTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func EwPATCHNoContent[Req any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return w.PATCHTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return w.PATCHTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは EwDELETENoRequest を使い、Responseを返さないケースでは EwDELETENoContent を使うこと。
 */
 func EwDELETE[Req any, Resp any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return w.DELETETyped(path, makeHandler(h), desc, req, resp, m...)
+	return w.DELETETyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func EwDELETENoRequest[Resp any](w *EchoWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return w.DELETETyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return w.DELETETyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func EwDELETENoContent[Req any](w *EchoWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return w.DELETETyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return w.DELETETyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 func (w *EchoWrapper) Group(prefix string, m ...echo.MiddlewareFunc) *GroupWrapper {
@@ -326,13 +452,54 @@ func (w *EchoWrapper) GroupWithVersionsAndFrontends(
 	frontends []string,
 	m ...echo.MiddlewareFunc,
 ) *GroupWrapper {
+	return w.GroupWithOptions(prefix, GroupOptions{
+		Versions:  versions,
+		Frontends: frontends,
+	}, m...)
+}
+
+// GroupWithOptions は、グループに属するエンドポイントのDescに適用するデフォルト値を指定してグループを作成する
+func (w *EchoWrapper) GroupWithOptions(prefix string, options GroupOptions, m ...echo.MiddlewareFunc) *GroupWrapper {
 	g := w.Echo.Group(prefix, m...)
 	return &GroupWrapper{
-		Group:     g,
-		prefix:    prefix,
-		versions:  versions,
-		frontends: frontends,
-		parent:    w,
+		Group:   g,
+		prefix:  prefix,
+		options: GroupOptions{}.merge(options),
+		parent:  w,
+	}
+}
+
+// SubGroup は、prefixをこのグループのprefixに連結したネストしたグループを作成する。
+// 作成されたグループは、このグループのGroupOptionsおよびmiddlewareを引き継ぐ。
+//
+// NOTE: GroupWrapper.Group はwrapされた*echo.Groupのフィールド名として使われているため、
+// EchoWrapper.Group に対応するメソッドはSubGroupという名前になっている
+func (g *GroupWrapper) SubGroup(prefix string, m ...echo.MiddlewareFunc) *GroupWrapper {
+	return g.GroupWithVersionsAndFrontends(prefix, nil, nil, m...)
+}
+
+// GroupWithVersionsAndFrontends は、versionsとfrontendsを追加で指定してネストしたグループを作成する。
+// versionsとfrontendsは、このグループに指定されたものと合わせたものがネストしたグループに設定される。
+func (g *GroupWrapper) GroupWithVersionsAndFrontends(
+	prefix string,
+	versions []string,
+	frontends []string,
+	m ...echo.MiddlewareFunc,
+) *GroupWrapper {
+	return g.GroupWithOptions(prefix, GroupOptions{
+		Versions:  versions,
+		Frontends: frontends,
+	}, m...)
+}
+
+// GroupWithOptions は、GroupOptionsを追加で指定してネストしたグループを作成する。
+// optionsは、このグループのGroupOptionsに対してDescと同じ規則で合わせられる。
+func (g *GroupWrapper) GroupWithOptions(prefix string, options GroupOptions, m ...echo.MiddlewareFunc) *GroupWrapper {
+	return &GroupWrapper{
+		Group:   g.Group.Group(prefix, m...),
+		prefix:  g.prefix + prefix,
+		options: g.options.merge(options),
+		parent:  g.parent,
 	}
 }
 
@@ -341,29 +508,11 @@ func (w *EchoWrapper) GroupWithVersionsAndFrontends(
 // （GroupWrapperが対応していないメソッドを使う場合など）
 // に限り、直接呼んでよい
 func (g *GroupWrapper) AddAPI(path string, desc Desc, method string) {
-	g.parent.endpoints.addAPI(API{
-		Name:       desc.Name,
-		Path:       g.prefix + path + desc.query(),
-		Desc:       desc.Desc,
-		Method:     method,
-		AuthSchema: desc.AuthSchema,
-		Versions:   append(g.versions, desc.Versions...),
-		Frontends:  append(g.frontends, desc.Frontends...),
-	})
+	g.parent.endpoints.addAPI(g.options.apply(desc).api(g.prefix+path, method, nil, nil))
 }
 
 func (g *GroupWrapper) AddAPITyped(path string, desc Desc, method string, req any, resp any) {
-	g.parent.endpoints.addAPI(API{
-		Name:       desc.Name,
-		Path:       g.prefix + path + desc.query(),
-		Desc:       desc.Desc,
-		Method:     method,
-		AuthSchema: desc.AuthSchema,
-		Request:    req,
-		Response:   resp,
-		Versions:   append(g.versions, desc.Versions...),
-		Frontends:  append(g.frontends, desc.Frontends...),
-	})
+	g.parent.endpoints.addAPI(g.options.apply(desc).api(g.prefix+path, method, req, resp))
 }
 
 func (g *GroupWrapper) GET(path string, h echo.HandlerFunc, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
@@ -396,6 +545,13 @@ func (g *GroupWrapper) GETTyped(path string, h echo.HandlerFunc, desc Desc, resp
 	return g.Group.GET(path, h, m...)
 }
 
+// GETTypedWithRequest は、GETTyped と異なりRequestの型を受け取る。
+// Requestはparam, queryタグによりパスパラメータとクエリパラメータとして扱われる。
+func (g *GroupWrapper) GETTypedWithRequest(path string, h echo.HandlerFunc, desc Desc, req any, resp any, m ...echo.MiddlewareFunc) *echo.Route {
+	g.AddAPITyped(path, desc, "GET", req, resp)
+	return g.Group.GET(path, h, m...)
+}
+
 func (g *GroupWrapper) POSTTyped(path string, h echo.HandlerFunc, desc Desc, req any, resp any, m ...echo.MiddlewareFunc) *echo.Route {
 	g.AddAPITyped(path, desc, "POST", req, resp)
 	return g.Group.POST(path, h, m...)
@@ -419,143 +575,158 @@ func (g *GroupWrapper) DELETETyped(path string, h echo.HandlerFunc, desc Desc, r
 /*
 EwGET のGroup版
 
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 NOTE: Go1.20時点では、メソッドがtype parameterをもてないので関数として定義されている
 */
 func GwGET[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.GETTyped(path, makeHandlerNoRequest(h), desc, resp, m...)
+	return g.GETTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, resp, m...)
+}
+
+/*
+EwGETWithRequest のGroup版
+
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、GETのAPIを生やす。Requestはパスパラメータとクエリパラメータから `param` `query` タグに従ってBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
+*/
+func GwGETWithRequest[Req any, Resp any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	var req Req
+	var resp Resp
+	return g.GETTypedWithRequest(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
 EwPOST のGroup版
 
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは GwPOSTNoRequest を使い、Responseを返さないケースでは GwPOSTNoContent を使うこと。
 */
 func GwPOST[Req any, Resp any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return g.POSTTyped(path, makeHandler(h), desc, req, resp, m...)
+	return g.POSTTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
 EwPOSTNoRequest のGroup版
 
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、POSTのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func GwPOSTNoRequest[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.POSTTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return g.POSTTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
 EwPOSTNoContent のGroup版
 
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、POSTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func GwPOSTNoContent[Req any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return g.POSTTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return g.POSTTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
 EwPUT のGroup版
 
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは GwPUTNoRequest を使い、Responseを返さないケースでは GwPUTNoContent を使うこと。
 */
 func GwPUT[Req any, Resp any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return g.PUTTyped(path, makeHandler(h), desc, req, resp, m...)
+	return g.PUTTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
 EwPUTNoRequest のGroup版
 
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PUTのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func GwPUTNoRequest[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.PUTTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return g.PUTTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
 EwPUTNoContent のGroup版
 
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、PUTのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func GwPUTNoContent[Req any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return g.PUTTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return g.PUTTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
 EwPATCH のGroup版
 
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは GwPATCHNoRequest を使い、Responseを返さないケースでは GwPATCHNoContent を使うこと。
 */
 func GwPATCH[Req any, Resp any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var req Req
 	var resp Resp
-	return g.PATCHTyped(path, makeHandler(h), desc, req, resp, m...)
+	return g.PATCHTyped(path, makeHandler(h, desc.status(http.StatusOK)), desc, req, resp, m...)
 }
 
 /*
 EwPATCHNoRequest のGroup版
 
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、PATCHのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func GwPATCHNoRequest[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.PATCHTyped(path, makeHandlerNoRequest(h), desc, nil, resp, m...)
+	return g.PATCHTyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, nil, resp, m...)
 }
 
 /*
 EwPATCHNoContent のGroup版
 
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、PATCHのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func GwPATCHNoContent[Req any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
+	desc.Status = desc.status(http.StatusNoContent)
 	var req Req
-	return g.PATCHTyped(path, makeHandlerNoContent(h), desc, req, nil, m...)
+	return g.PATCHTyped(path, makeHandlerNoContent(h, desc.Status), desc, req, nil, m...)
 }
 
 /*
 EwDELETE のGroup版
 
-func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context, Req) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 
 Requestを受け取らないケースでは GwDELETENoRequest を使い、Responseを返さないケースでは GwDELETENoContent を使うこと。
 */
 func GwDELETE[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.DELETETyped(path, makeHandlerNoRequest(h), desc, resp, m...)
+	return g.DELETETyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, resp, m...)
 }
 
 /*
 EwDELETENoRequest のGroup版
 
-func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200、bodyとしてJSONで返す。
+func(echo.Context) (Resp, error)の型をもつhandlerを受け取り、DELETEのAPIを生やす。Responseはstatusとして200 (Desc.Statusが指定されている場合はそのstatus)、bodyとしてJSONで返す。
 */
 func GwDELETENoRequest[Resp any](g *GroupWrapper, path string, h func(ctx echo.Context) (Resp, error), desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
 	var resp Resp
-	return g.DELETETyped(path, makeHandlerNoRequest(h), desc, resp, m...)
+	return g.DELETETyped(path, makeHandlerNoRequest(h, desc.status(http.StatusOK)), desc, resp, m...)
 }
 
 /*
 EwDELETENoContent のGroup版
 
-func(echo.Context, Req) errorの型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204を返す。
+func(echo.Context, Req) errorの型をもつhandlerを受け取り、DELETEのAPIを生やす。RequestはJSONとしてBindする。Responseはstatusとして204 (Desc.Statusが指定されている場合はそのstatus) を返す。
 */
 func GwDELETENoContent[Req any](g *GroupWrapper, path string, h func(ctx echo.Context, req Req) error, desc Desc, m ...echo.MiddlewareFunc) *echo.Route {
-	return g.DELETETyped(path, makeHandlerNoContent(h), desc, nil, m...)
+	desc.Status = desc.status(http.StatusNoContent)
+	return g.DELETETyped(path, makeHandlerNoContent(h, desc.Status), desc, nil, m...)
 }
 
 type Desc struct {
@@ -565,6 +736,25 @@ type Desc struct {
 	AuthSchema AuthSchema
 	Versions   []string
 	Frontends  []string
+
+	// OpenAPIのtagとして追加される
+	Tags []string
+	// trueの場合、非推奨のエンドポイントとして出力される
+	Deprecated bool
+	// trueの場合、.endpoints.jsonやOpenAPIに出力されない
+	Hidden bool
+	// 任意の追加情報
+	Metadata map[string]string
+	// レスポンスのステータスコード e.g. http.StatusCreated
+	// 型付きハンドラ (EwPOSTなど) では実際に返すstatusとしても使われる
+	// 指定がない場合、200 (NoContent系の型付きハンドラでは204) とみなす
+	Status int
+	// エンドポイントが返しうるエラーレスポンス
+	// .endpoints.jsonのerrorsとOpenAPIのresponsesに出力される
+	Errors []ErrorResponse
+	// レスポンスの例. .endpoints.jsonのexampleとOpenAPIのexampleに出力され、モックサーバーが返す
+	// 指定がない場合、モックサーバーはレスポンスのスキーマから例を生成する
+	Example any
 }
 
 func (d *Desc) query() string {
@@ -573,3 +763,69 @@ func (d *Desc) query() string {
 	}
 	return "?" + d.Query
 }
+
+// api は、EchoWrapperとGroupWrapperのどちらから登録される場合でも、
+// Descの全てのフィールドを同じ規則でAPIに対応させる
+func (d Desc) api(path string, method string, req any, resp any) API {
+	return API{
+		Name:       d.Name,
+		Path:       path + d.query(),
+		Desc:       d.Desc,
+		Method:     method,
+		AuthSchema: d.AuthSchema,
+		Request:    req,
+		Response:   resp,
+		Versions:   mergeStrings(d.Versions, nil),
+		Frontends:  mergeStrings(d.Frontends, nil),
+		Tags:       mergeStrings(d.Tags, nil),
+		Deprecated: d.Deprecated,
+		Hidden:     d.Hidden,
+		Metadata:   mergeMetadata(d.Metadata, nil),
+		Status:     d.Status,
+		Errors:     slices.Clone(d.Errors),
+		Example:    d.Example,
+	}
+}
+
+// status は、Statusが指定されている場合はそれを、指定されていない場合はdefaultStatusを返す
+func (d Desc) status(defaultStatus int) int {
+	if d.Status != 0 {
+		return d.Status
+	}
+	return defaultStatus
+}
+
+// mergeStrings は、aとbを重複を除いて連結した新しいsliceを返す。
+// 元のsliceのbacking arrayを共有しないように、常に新しくsliceを確保する。
+func mergeStrings(a, b []string) []string {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	merged := make([]string, 0, len(a)+len(b))
+	seen := map[string]struct{}{}
+	for _, s := range [][]string{a, b} {
+		for _, v := range s {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+			merged = append(merged, v)
+		}
+	}
+	return merged
+}
+
+// mergeMetadata は、parentとchildを合わせた新しいmapを返す。同じキーがある場合はchildの値が優先される。
+func mergeMetadata(parent, child map[string]string) map[string]string {
+	if len(parent) == 0 && len(child) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(parent)+len(child))
+	for k, v := range parent {
+		merged[k] = v
+	}
+	for k, v := range child {
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,342 @@
+package endpoints
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+)
+
+// generateZod は、$defsの型のZodスキーマと、
+// .endpoints.jsonのkey ("v1", "guest-v1"など) ごとのエンドポイントのリクエスト・レスポンスのスキーマを生成する
+func (e *endpoints) generateZod() ([]byte, error) {
+	merged, renames := mergeDefs(e.collectAllDefs())
+
+	var b bytes.Buffer
+	b.WriteString(typeScriptHeader)
+	b.WriteString("import { z } from \"zod\";\n")
+
+	g := &zodGenerator{defs: merged, emitted: map[string]bool{}, visiting: map[string]bool{}}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if err := checkIdentifiers(names, typeScriptIdentifier); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		g.writeDef(&b, name)
+	}
+
+	b.WriteString("\nexport const endpoints = {\n")
+	for _, k := range e.documentKeys() {
+		fmt.Fprintf(&b, "  %s: {\n", typeScriptPropertyName(k.key))
+		for _, api := range e.filterAPI(k.env.Version, k.frontend) {
+			request := "z.void()"
+			if api.hasRequestBody() {
+				request = g.schemaOf(api.Request, true, renames)
+			}
+			response := "z.void()"
+			if api.Response != nil && statusHasBody(api.status()) {
+				response = g.schemaOf(api.Response, false, renames)
+			}
+			fmt.Fprintf(&b, "    %s: {\n", typeScriptPropertyName(api.Name))
+			fmt.Fprintf(&b, "      method: %q,\n", api.Method)
+			fmt.Fprintf(&b, "      path: %s,\n", typeScriptLiteral(strings.TrimPrefix(api.Path, "/")))
+			fmt.Fprintf(&b, "      request: %s,\n", request)
+			fmt.Fprintf(&b, "      response: %s,\n", response)
+			b.WriteString("    },\n")
+		}
+		b.WriteString("  },\n")
+	}
+	b.WriteString("} as const;\n")
+	return b.Bytes(), nil
+}
+
+func (e *endpoints) generateZodFile(filename string) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	bs, err := e.generateZod()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, bs, 0o644)
+}
+
+// zodGenerator converts JSON Schema into Zod schema expressions.
+// Definitions are emitted after the definitions they reference; references that
+// cannot be ordered that way (recursive types) are wrapped in z.lazy.
+type zodGenerator struct {
+	defs     jsonschema.Definitions
+	emitted  map[string]bool
+	visiting map[string]bool
+	// lazy is set while converting a definition that refers to one not emitted yet
+	lazy bool
+}
+
+// writeDef writes the definition name as <name>Schema and its inferred type,
+// after writing the definitions it references.
+func (g *zodGenerator) writeDef(b *bytes.Buffer, name string) {
+	if g.emitted[name] || g.visiting[name] {
+		return
+	}
+	s, ok := g.defs[name]
+	if !ok {
+		return
+	}
+	g.visiting[name] = true
+	for _, ref := range schemaRefs(s) {
+		g.writeDef(b, ref)
+	}
+	delete(g.visiting, name)
+
+	g.lazy = false
+	schema := g.schema(s, "")
+	g.emitted[name] = true
+
+	id := typeScriptIdentifier(name)
+	b.WriteString("\n")
+	writeTypeScriptComment(b, "", s.Description, s.Deprecated)
+	if g.lazy {
+		// The type of a recursive schema cannot be inferred
+		fmt.Fprintf(b, "export const %sSchema: z.ZodTypeAny = %s;\n", id, schema)
+	} else {
+		fmt.Fprintf(b, "export const %sSchema = %s;\n", id, schema)
+	}
+	fmt.Fprintf(b, "export type %s = z.infer<typeof %sSchema>;\n", id, id)
+}
+
+// schemaOf returns the Zod schema of the Go value typ (a request body if request is true),
+// referring to renamed $defs.
+func (g *zodGenerator) schemaOf(typ any, request bool, renames map[string]string) string {
+	s, _ := reflectTypeAs(typ, request)
+	if s.Ref != "" {
+		return g.schema(&jsonschema.Schema{Ref: applyRenameToRef(s.Ref, renames)}, "")
+	}
+	rewriteRefs(s, renames)
+	return g.schema(s, "      ")
+}
+
+// schema converts s into a Zod schema expression.
+// indent is the indentation of the line the expression starts on.
+func (g *zodGenerator) schema(s *jsonschema.Schema, indent string) string {
+	if s == nil {
+		return "z.unknown()"
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/$defs/")
+		if !g.emitted[name] {
+			g.lazy = true
+			return fmt.Sprintf("z.lazy(() => %sSchema)", typeScriptIdentifier(name))
+		}
+		return typeScriptIdentifier(name) + "Schema"
+	}
+	if s.Const != nil {
+		return fmt.Sprintf("z.literal(%s)", typeScriptLiteral(s.Const))
+	}
+	if len(s.Enum) > 0 {
+		return zodEnum(s.Enum)
+	}
+
+	var parts []string
+	if t := g.baseSchema(s, indent); t != "z.unknown()" {
+		parts = append(parts, t)
+	}
+	if subs := append(slices.Clone(s.OneOf), s.AnyOf...); len(subs) > 0 {
+		rest, nullable := withoutNullSchemas(subs)
+		var union string
+		switch len(rest) {
+		case 0:
+			union = "z.null()"
+			nullable = false
+		case 1:
+			union = g.schema(rest[0], indent)
+		default:
+			var members []string
+			for _, sub := range rest {
+				members = append(members, g.schema(sub, indent))
+			}
+			union = "z.union([" + strings.Join(members, ", ") + "])"
+		}
+		if nullable {
+			union += ".nullable()"
+		}
+		parts = append(parts, union)
+	}
+	for _, sub := range s.AllOf {
+		// allOf entries holding only constraints add nothing to the schema
+		if t := g.schema(sub, indent); t != "z.unknown()" {
+			parts = append(parts, t)
+		}
+	}
+	if len(parts) == 0 {
+		return "z.unknown()"
+	}
+	schema := parts[0]
+	for _, p := range parts[1:] {
+		schema += ".and(" + p + ")"
+	}
+	return schema
+}
+
+// baseSchema converts the type keyword of s and its validation keywords, ignoring composition keywords.
+func (g *zodGenerator) baseSchema(s *jsonschema.Schema, indent string) string {
+	switch s.Type {
+	case "string":
+		return "z.string()" + zodStringChecks(s)
+	case "integer":
+		return "z.number().int()" + zodNumberChecks(s)
+	case "number":
+		return "z.number()" + zodNumberChecks(s)
+	case "boolean":
+		return "z.boolean()"
+	case "null":
+		return "z.null()"
+	case "array":
+		return "z.array(" + g.schema(s.Items, indent) + ")" + zodArrayChecks(s)
+	case "object", "":
+		if s.Properties != nil && s.Properties.Len() > 0 {
+			return g.object(s, indent)
+		}
+		if s.Type == "" {
+			return "z.unknown()"
+		}
+		if s.AdditionalProperties != nil && s.AdditionalProperties.Not == nil {
+			return "z.record(z.string(), " + g.schema(s.AdditionalProperties, indent) + ")"
+		}
+		return "z.record(z.string(), z.unknown())"
+	default:
+		return "z.unknown()"
+	}
+}
+
+func (g *zodGenerator) object(s *jsonschema.Schema, indent string) string {
+	var b bytes.Buffer
+	b.WriteString("z.object({\n")
+	for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+		writeTypeScriptComment(&b, indent+"  ", pair.Value.Description, pair.Value.Deprecated)
+		schema := g.schema(pair.Value, indent+"  ")
+		if !slices.Contains(s.Required, pair.Key) {
+			schema += ".optional()"
+		}
+		fmt.Fprintf(&b, "%s  %s: %s,\n", indent, typeScriptPropertyName(pair.Key), schema)
+	}
+	b.WriteString(indent + "})")
+	return b.String()
+}
+
+func zodEnum(values []any) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := v.(string); !ok {
+			break
+		}
+		strs = append(strs, typeScriptLiteral(v))
+	}
+	if len(strs) == len(values) {
+		return "z.enum([" + strings.Join(strs, ", ") + "])"
+	}
+	if len(values) == 1 {
+		return fmt.Sprintf("z.literal(%s)", typeScriptLiteral(values[0]))
+	}
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		literals = append(literals, fmt.Sprintf("z.literal(%s)", typeScriptLiteral(v)))
+	}
+	return "z.union([" + strings.Join(literals, ", ") + "])"
+}
+
+// zodStringFormats maps JSON Schema formats to the Zod string checks validating them.
+func zodStringFormats() map[string]string {
+	return map[string]string{
+		"email":     ".email()",
+		"uuid":      ".uuid()",
+		"uri":       ".url()",
+		"date-time": ".datetime({ offset: true })",
+		"date":      ".date()",
+		"ipv4":      ".ip({ version: \"v4\" })",
+		"ipv6":      ".ip({ version: \"v6\" })",
+	}
+}
+
+func zodStringChecks(s *jsonschema.Schema) string {
+	var checks string
+	if s.MinLength != nil {
+		checks += fmt.Sprintf(".min(%d)", *s.MinLength)
+	}
+	if s.MaxLength != nil {
+		checks += fmt.Sprintf(".max(%d)", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		checks += fmt.Sprintf(".regex(new RegExp(%s))", typeScriptLiteral(s.Pattern))
+	}
+	checks += zodStringFormats()[s.Format]
+	return checks
+}
+
+func zodNumberChecks(s *jsonschema.Schema) string {
+	var checks string
+	if s.Minimum != "" {
+		checks += fmt.Sprintf(".gte(%s)", s.Minimum)
+	}
+	if s.ExclusiveMinimum != "" {
+		checks += fmt.Sprintf(".gt(%s)", s.ExclusiveMinimum)
+	}
+	if s.Maximum != "" {
+		checks += fmt.Sprintf(".lte(%s)", s.Maximum)
+	}
+	if s.ExclusiveMaximum != "" {
+		checks += fmt.Sprintf(".lt(%s)", s.ExclusiveMaximum)
+	}
+	return checks
+}
+
+func zodArrayChecks(s *jsonschema.Schema) string {
+	var checks string
+	if s.MinItems != nil {
+		checks += fmt.Sprintf(".min(%d)", *s.MinItems)
+	}
+	if s.MaxItems != nil {
+		checks += fmt.Sprintf(".max(%d)", *s.MaxItems)
+	}
+	return checks
+}
+
+// schemaRefs returns the names of the $defs referenced from s, in order of appearance.
+func schemaRefs(s *jsonschema.Schema) []string {
+	var refs []string
+	var walk func(s *jsonschema.Schema)
+	walk = func(s *jsonschema.Schema) {
+		if s == nil {
+			return
+		}
+		if name, ok := strings.CutPrefix(s.Ref, "#/$defs/"); ok && !slices.Contains(refs, name) {
+			refs = append(refs, name)
+		}
+		if s.Properties != nil {
+			for pair := s.Properties.Oldest(); pair != nil; pair = pair.Next() {
+				walk(pair.Value)
+			}
+		}
+		walk(s.Items)
+		walk(s.AdditionalProperties)
+		for _, sub := range s.AllOf {
+			walk(sub)
+		}
+		for _, sub := range s.AnyOf {
+			walk(sub)
+		}
+		for _, sub := range s.OneOf {
+			walk(sub)
+		}
+	}
+	walk(s)
+	return refs
+}